@@ -8,6 +8,7 @@ import { AppBarContext } from './contexts/AppBarContext';
 import { SWRConfig } from 'swr';
 import fetchJson from './lib/fetchJson';
 import Search from './pages/search';
+import Audit from './pages/audit';
 
 export type Config = {
   title: string;
@@ -44,6 +45,7 @@ function App({ config }: Props) {
               <Route path="/dags/" element={<DAGs />} />
               <Route path="/dags/:name/*" element={<DAGDetails />} />
               <Route path="/search/" element={<Search />} />
+              <Route path="/audit/" element={<Audit />} />
             </Routes>
           </Layout>
         </BrowserRouter>
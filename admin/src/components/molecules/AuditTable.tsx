@@ -0,0 +1,64 @@
+import moment from 'moment';
+import React from 'react';
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableRow,
+} from '@mui/material';
+import { Link } from 'react-router-dom';
+import { AuditEntry } from '../../models/api';
+import BorderedBox from '../atoms/BorderedBox';
+import StyledTableRow from '../atoms/StyledTableRow';
+
+type Props = {
+  entries: AuditEntry[];
+};
+
+function AuditTable({ entries }: Props) {
+  return (
+    <BorderedBox>
+      <Table size="small">
+        <TableHead>
+          <TableRow>
+            <TableCell>Timestamp</TableCell>
+            <TableCell>User</TableCell>
+            <TableCell>Remote Address</TableCell>
+            <TableCell>DAG</TableCell>
+            <TableCell>Action</TableCell>
+            <TableCell>Params</TableCell>
+            <TableCell>Diff</TableCell>
+          </TableRow>
+        </TableHead>
+        <TableBody>
+          {entries.map((e, i) => (
+            <StyledTableRow key={i}>
+              <TableCell>
+                {moment(e.Timestamp).format('YYYY-MM-DD HH:mm:ss')}
+              </TableCell>
+              <TableCell>{e.User}</TableCell>
+              <TableCell>{e.RemoteAddr}</TableCell>
+              <TableCell>
+                <Link to={`/dags/${encodeURIComponent(e.DAG)}`}>{e.DAG}</Link>
+              </TableCell>
+              <TableCell>{e.Action}</TableCell>
+              <TableCell>
+                {Object.entries(e.Params || {})
+                  .map(([k, v]) => `${k}=${v}`)
+                  .join(', ')}
+              </TableCell>
+              <TableCell>
+                {e.Diff ? (
+                  <pre style={{ margin: 0 }}>{e.Diff}</pre>
+                ) : null}
+              </TableCell>
+            </StyledTableRow>
+          ))}
+        </TableBody>
+      </Table>
+    </BorderedBox>
+  );
+}
+
+export default AuditTable;
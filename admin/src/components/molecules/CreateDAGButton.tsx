@@ -31,7 +31,9 @@ function CreateDAGButton() {
           body: formData,
         });
         if (resp.ok) {
-          window.location.href = `/dags/${name.replace(/.yaml$/, '')}/spec`;
+          window.location.href = `/dags/${encodeURIComponent(
+            name.replace(/.yaml$/, '')
+          )}/spec`;
         } else {
           const e = await resp.text();
           alert(e);
@@ -14,6 +14,7 @@ type LabelProps = {
 
 type Props = {
   status?: Status;
+  overlapPolicy?: string;
   name: string;
   label?: boolean;
   redirectTo?: string;
@@ -27,6 +28,7 @@ function Label({ show, children }: LabelProps): JSX.Element {
 
 function DAGActions({
   status,
+  overlapPolicy,
   name,
   refresh,
   redirectTo,
@@ -63,7 +65,7 @@ function DAGActions({
       if (params.requestId) {
         form.set('request-id', params.requestId);
       }
-      const url = `${API_URL}/dags/${params.name}`;
+      const url = `${API_URL}/dags/${encodeURIComponent(params.name)}`;
       const ret = await fetch(url, {
         method: 'POST',
         mode: 'cors',
@@ -84,12 +86,14 @@ function DAGActions({
   );
   const buttonState = React.useMemo(
     () => ({
-      start: status?.Status != SchedulerStatus.Running,
+      start:
+        status?.Status != SchedulerStatus.Running ||
+        (!!overlapPolicy && overlapPolicy != 'skip'),
       stop: status?.Status == SchedulerStatus.Running,
       retry:
         status?.Status != SchedulerStatus.Running && status?.RequestId != '',
     }),
-    [status]
+    [status, overlapPolicy]
   );
   return (
     <Stack direction="row" spacing={2}>
@@ -1,7 +1,7 @@
 import React from 'react';
 import { Stack, Box, Chip } from '@mui/material';
 import LabeledItem from '../atoms/LabeledItem';
-import { DAG } from '../../models';
+import { conditionText, DAG } from '../../models';
 
 type Props = {
   dag: DAG;
@@ -9,11 +9,7 @@ type Props = {
 
 function DAGAttributes({ dag: config }: Props) {
   const preconditions = config.Preconditions?.map((c) => (
-    <li>
-      {c.Condition}
-      {' => '}
-      {c.Expected}
-    </li>
+    <li>{conditionText(c)}</li>
   ));
   return (
     <Stack direction="column" spacing={1}>
@@ -33,6 +29,9 @@ function DAGAttributes({ dag: config }: Props) {
           ))}
         </Stack>
       </LabeledItem>
+      {config.Timezone ? (
+        <LabeledItem label="Timezone">{config.Timezone}</LabeledItem>
+      ) : null}
       <LabeledItem label="Description">{config.Description}</LabeledItem>
       <LabeledItem label="Max Active Runs">{config.MaxActiveRuns}</LabeledItem>
       <LabeledItem label="Params">{config.Params?.join(' ')}</LabeledItem>
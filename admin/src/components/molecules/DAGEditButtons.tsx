@@ -21,14 +21,14 @@ function DAGEditButtons({ name }: Props) {
           const formData = new FormData();
           formData.append('action', 'rename');
           formData.append('value', val);
-          const url = `${API_URL}/dags/${name}`;
+          const url = `${API_URL}/dags/${encodeURIComponent(name)}`;
           const resp = await fetch(url, {
             method: 'POST',
             headers: { Accept: 'application/json' },
             body: formData,
           });
           if (resp.ok) {
-            window.location.href = `/dags/${val}`;
+            window.location.href = `/dags/${encodeURIComponent(val)}`;
           } else {
             const e = await resp.text();
             alert(e);
@@ -42,7 +42,7 @@ function DAGEditButtons({ name }: Props) {
           if (!confirm('Are you sure to delete the DAG?')) {
             return;
           }
-          const url = `${API_URL}/dags/${name}`;
+          const url = `${API_URL}/dags/${encodeURIComponent(name)}`;
           const resp = await fetch(url, {
             method: 'DELETE',
             headers: { Accept: 'application/json' },
@@ -1,5 +1,5 @@
 import React from 'react';
-import { Status } from '../../models';
+import { dagNameFromFile, Status } from '../../models';
 import StatusChip from '../atoms/StatusChip';
 import { Stack } from '@mui/material';
 import LabeledItem from '../atoms/LabeledItem';
@@ -12,7 +12,7 @@ type Props = {
 };
 
 function DAGStatusOverview({ status, name, file = '' }: Props) {
-  const url = `/dags/${name}/scheduler-log?&file=${encodeURI(file)}`;
+  const url = `/dags/${encodeURIComponent(name)}/scheduler-log?&file=${encodeURI(file)}`;
   if (!status) {
     return null;
   }
@@ -27,6 +27,51 @@ function DAGStatusOverview({ status, name, file = '' }: Props) {
         <LabeledItem label="Finished At">{status.FinishedAt}</LabeledItem>
       </Stack>
       <LabeledItem label="Params">{status.Params}</LabeledItem>
+      {status.ScheduledTime ? (
+        <LabeledItem label="Scheduled At">{status.ScheduledTime}</LabeledItem>
+      ) : null}
+      {status.BackfillId ? (
+        <LabeledItem label="Backfill ID">{status.BackfillId}</LabeledItem>
+      ) : null}
+      {status.Revision ? (
+        <LabeledItem label="Revision">
+          {status.Revision.substring(0, 8)}
+        </LabeledItem>
+      ) : null}
+      {status.Parent ? (
+        <LabeledItem label="Parent">
+          <Link
+            to={`/dags/${encodeURIComponent(dagNameFromFile(status.Parent))}`}
+          >
+            {dagNameFromFile(status.Parent)}
+          </Link>{' '}
+          ({status.ParentRequestId})
+        </LabeledItem>
+      ) : null}
+      {status.Outputs
+        ? Object.entries(status.Outputs).map(([key, value]) => (
+            <LabeledItem key={key} label={`Output ${key}`}>
+              {value}
+            </LabeledItem>
+          ))
+        : null}
+      {status.Artifacts && status.Artifacts.length > 0 ? (
+        <LabeledItem label="Artifacts">
+          <Stack direction="column">
+            {status.Artifacts.map((a) => (
+              <a
+                key={a}
+                href={`/dags/${encodeURIComponent(name)}/artifact?requestId=${encodeURIComponent(
+                  status.RequestId
+                )}&name=${encodeURIComponent(a)}`}
+                download
+              >
+                {a}
+              </a>
+            ))}
+          </Stack>
+        </LabeledItem>
+      ) : null}
       <LabeledItem label="Scheduler Log">
         <Link to={url}>{status.Log}</Link>
       </LabeledItem>
@@ -1,5 +1,5 @@
 import React from 'react';
-import { Step } from '../../models';
+import { conditionText, Step } from '../../models';
 import { TableCell } from '@mui/material';
 import StyledTableRow from '../atoms/StyledTableRow';
 import MultilineText from '../atoms/MultilineText';
@@ -10,11 +10,7 @@ type Props = {
 
 function DAGStepTableRow({ step }: Props) {
   const preconditions = step.Preconditions.map((c) => (
-    <li>
-      {c.Condition}
-      {' => '}
-      {c.Expected}
-    </li>
+    <li>{conditionText(c)}</li>
   ));
   return (
     <StyledTableRow>
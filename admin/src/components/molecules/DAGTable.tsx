@@ -35,6 +35,7 @@ import {
   DAGItem,
   DAGDataType,
   getNextSchedule,
+  getNextScheduleTimezone,
 } from '../../models';
 import StyledTableRow from '../atoms/StyledTableRow';
 import {
@@ -114,7 +115,7 @@ const defaultColumns = [
         return getValue();
       } else {
         const name = data.DAGStatus.File.replace(/.y[a]{0,1}ml$/, '');
-        const url = `/dags/${encodeURI(name)}`;
+        const url = `/dags/${encodeURIComponent(name)}`;
         return (
           <div
             style={{
@@ -196,9 +197,17 @@ const defaultColumns = [
       const data = props.row.original!;
       if (data.Type == DAGDataType.DAG) {
         return (
-          <StatusChip status={data.DAGStatus.Status?.Status}>
-            {data.DAGStatus.Status?.StatusText || ''}
-          </StatusChip>
+          <React.Fragment>
+            <StatusChip status={data.DAGStatus.Status?.Status}>
+              {data.DAGStatus.Status?.StatusText || ''}
+            </StatusChip>
+            {(data.DAGStatus.Running?.length || 0) > 1 ? (
+              <span> ({data.DAGStatus.Running!.length} running)</span>
+            ) : null}
+            {data.DAGStatus.Queue?.length ? (
+              <span> ({data.DAGStatus.Queue.length} queued)</span>
+            ) : null}
+          </React.Fragment>
         );
       }
       return null;
@@ -265,6 +274,7 @@ const defaultColumns = [
                   }}
                   size="small"
                   label={s.Expression}
+                  title={s.Timezone}
                 />
               ))}
             </React.Fragment>
@@ -313,6 +323,9 @@ const defaultColumns = [
                   );
                 }}
               </Ticker>
+              {getNextScheduleTimezone(data.DAGStatus) ? (
+                <span> ({getNextScheduleTimezone(data.DAGStatus)})</span>
+              ) : null}
             </React.Fragment>
           );
         }
@@ -372,6 +385,7 @@ const defaultColumns = [
       return (
         <DAGActions
           status={data.DAGStatus.Status}
+          overlapPolicy={data.DAGStatus.DAG.OverlapPolicy}
           name={data.DAGStatus.DAG.Name}
           label={false}
           refresh={props.table.options.meta?.refreshFn}
@@ -60,7 +60,10 @@ function Graph({
       }
     };
     if (type == 'status') {
-      (steps as Node[]).forEach((s) => addNodeFn(s.Step, s.Status));
+      // the nodes expanded from a parallel step are shown as the step
+      (steps as Node[])
+        .filter((s) => !s.ParallelParent)
+        .forEach((s) => addNodeFn(s.Step, s.Status));
     } else {
       (steps as Step[]).forEach((s) => addNodeFn(s, NodeStatus.None));
     }
@@ -73,6 +76,8 @@ function Graph({
     dat.push('classDef cancel fill:white,stroke:pink,stroke-width:2px');
     dat.push('classDef done fill:white,stroke:green,stroke-width:2px');
     dat.push('classDef skipped fill:white,stroke:gray,stroke-width:2px');
+    dat.push('classDef timeout fill:white,stroke:orange,stroke-width:2px');
+    dat.push('classDef queued fill:white,stroke:khaki,stroke-width:2px');
     return dat.join('\n');
   }, [steps, onClickNode, flowchart]);
   return <Mermaid style={mermaidStyle} def={graph} />;
@@ -87,4 +92,6 @@ const graphStatusMap = {
   [NodeStatus.Cancel]: ':::cancel',
   [NodeStatus.Success]: ':::done',
   [NodeStatus.Skipped]: ':::skipped',
+  [NodeStatus.Timeout]: ':::timeout',
+  [NodeStatus.Queued]: ':::queued',
 };
@@ -16,7 +16,12 @@ function HistoryTableRow({ data, onSelect, idx }: Props) {
   }, [data]);
   return (
     <StyledTableRow>
-      <TableCell>{data.Name}</TableCell>
+      <TableCell
+        title={data.BackfillId}
+        sx={data.BackfillId ? { fontStyle: 'italic' } : undefined}
+      >
+        {data.Name}
+      </TableCell>
       {vals.map((status, i) => {
         const style: CSSProperties = { ...circleStyle };
         const tdStyle: CSSProperties = { maxWidth: '22px' };
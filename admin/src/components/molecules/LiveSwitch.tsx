@@ -15,7 +15,7 @@ function LiveSwitch({ DAG, refresh, inputProps }: Props) {
       const form = new FormData();
       form.set('action', params.action);
       form.set('value', params.value);
-      const url = `${API_URL}/dags/${params.name}`;
+      const url = `${API_URL}/dags/${encodeURIComponent(params.name)}`;
       const ret = await fetch(url, {
         method: 'POST',
         mode: 'cors',
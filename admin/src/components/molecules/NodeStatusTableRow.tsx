@@ -1,5 +1,5 @@
 import React from 'react';
-import { Node, Step } from '../../models';
+import { dagNameFromFile, Node, Step } from '../../models';
 import MultilineText from '../atoms/MultilineText';
 import NodeStatusChip from '../molecules/NodeStatusChip';
 import { TableCell } from '@mui/material';
@@ -22,7 +22,7 @@ function NodeStatusTableRow({
   file,
   onRequireModal,
 }: Props) {
-  const url = `/dags/${name}/log?file=${file}&step=${node.Step.Name}`;
+  const url = `/dags/${encodeURIComponent(name)}/log?file=${file}&step=${node.Step.Name}`;
   const buttonStyle = {
     margin: '0px',
     padding: '0px',
@@ -33,12 +33,32 @@ function NodeStatusTableRow({
   return (
     <StyledTableRow>
       <TableCell> {rownum} </TableCell>
-      <TableCell> {node.Step.Name} </TableCell>
+      <TableCell>
+        {node.Step.Run ? (
+          <Link
+            to={`/dags/${encodeURIComponent(dagNameFromFile(node.Step.Run))}${
+              node.ChildRequestId
+                ? `?requestId=${encodeURIComponent(node.ChildRequestId)}`
+                : ''
+            }`}
+          >
+            {node.Step.Name}
+          </Link>
+        ) : (
+          node.Step.Name
+        )}
+      </TableCell>
       <TableCell>
         <MultilineText>{node.Step.Description}</MultilineText>
       </TableCell>
-      <TableCell> {node.Step.Command} </TableCell>
-      <TableCell> {node.Step.Args ? node.Step.Args.join(' ') : ''} </TableCell>
+      <TableCell> {node.Step.Run || node.Step.Command} </TableCell>
+      <TableCell>
+        {node.Step.Run
+          ? node.Step.Params
+          : node.Step.Args
+          ? node.Step.Args.join(' ')
+          : ''}
+      </TableCell>
       <TableCell> {node.StartedAt} </TableCell>
       <TableCell> {node.FinishedAt} </TableCell>
       <TableCell>
@@ -48,7 +68,7 @@ function NodeStatusTableRow({
           </NodeStatusChip>
         </button>
       </TableCell>
-      <TableCell> {node.Error} </TableCell>
+      <TableCell> {node.SkipReason || node.Error} </TableCell>
       <TableCell>
         {node.Log ? (
           <Link to={url}>
@@ -0,0 +1,53 @@
+import moment from 'moment';
+import React from 'react';
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableRow,
+} from '@mui/material';
+import { QueuedRun } from '../../models';
+import BorderedBox from '../atoms/BorderedBox';
+import StyledTableRow from '../atoms/StyledTableRow';
+
+type Props = {
+  queue: QueuedRun[];
+};
+
+function QueueTable({ queue }: Props) {
+  return (
+    <BorderedBox>
+      <Table size="small">
+        <TableHead>
+          <TableRow>
+            <TableCell>Position</TableCell>
+            <TableCell>Request ID</TableCell>
+            <TableCell>Queued At</TableCell>
+            <TableCell>Scheduled At</TableCell>
+            <TableCell>Params</TableCell>
+          </TableRow>
+        </TableHead>
+        <TableBody>
+          {queue.map((q, i) => (
+            <StyledTableRow key={q.RequestId}>
+              <TableCell>{i + 1}</TableCell>
+              <TableCell>{q.RequestId}</TableCell>
+              <TableCell>
+                {moment(q.QueuedAt).format('YYYY-MM-DD HH:mm:ss')}
+              </TableCell>
+              <TableCell>
+                {q.ScheduledTime
+                  ? moment(q.ScheduledTime).format('YYYY-MM-DD HH:mm:ss')
+                  : '-'}
+              </TableCell>
+              <TableCell>{q.Params}</TableCell>
+            </StyledTableRow>
+          ))}
+        </TableBody>
+      </Table>
+    </BorderedBox>
+  );
+}
+
+export default QueueTable;
@@ -0,0 +1,149 @@
+import moment from 'moment';
+import React from 'react';
+import {
+  Box,
+  Button,
+  Radio,
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableRow,
+} from '@mui/material';
+import { GetRevisionsResponse, RevisionDiff } from '../../models/api';
+import BorderedBox from '../atoms/BorderedBox';
+import StyledTableRow from '../atoms/StyledTableRow';
+
+type Props = {
+  name: string;
+  definition: string;
+  refresh: () => void;
+};
+
+function RevisionTable({ name, definition, refresh }: Props) {
+  const [revisions, setRevisions] = React.useState<GetRevisionsResponse>();
+  const [from, setFrom] = React.useState('');
+  const [to, setTo] = React.useState('');
+  const [diff, setDiff] = React.useState<RevisionDiff>();
+  const base = `${API_URL}/api/v1/dags/${encodeURIComponent(name)}/revisions`;
+
+  React.useEffect(() => {
+    (async () => {
+      const resp = await fetch(base, {
+        headers: { Accept: 'application/json' },
+      });
+      if (resp.ok) {
+        setRevisions(await resp.json());
+      }
+    })();
+  }, [base, definition]);
+
+  React.useEffect(() => {
+    if (!from) {
+      setDiff(undefined);
+      return;
+    }
+    (async () => {
+      const q = to ? `?to=${to}` : '';
+      const resp = await fetch(`${base}/${from}/diff${q}`, {
+        headers: { Accept: 'application/json' },
+      });
+      if (resp.ok) {
+        setDiff(await resp.json());
+      }
+    })();
+  }, [base, from, to, definition]);
+
+  const rollback = React.useCallback(
+    async (hash: string) => {
+      if (!confirm(`Roll back to revision ${hash.substring(0, 8)}?`)) {
+        return;
+      }
+      const formData = new FormData();
+      formData.append('action', 'rollback');
+      formData.append('value', hash);
+      const resp = await fetch(`${API_URL}/dags/${encodeURIComponent(name)}`, {
+        method: 'POST',
+        headers: { Accept: 'application/json' },
+        body: formData,
+      });
+      if (resp.ok) {
+        refresh();
+      } else {
+        alert(await resp.text());
+      }
+    },
+    [name, refresh]
+  );
+
+  if (!revisions?.Revisions?.length) {
+    return <Box sx={{ color: 'grey.600' }}>No revisions</Box>;
+  }
+  return (
+    <React.Fragment>
+      <BorderedBox>
+        <Table size="small">
+          <TableHead>
+            <TableRow>
+              <TableCell>From</TableCell>
+              <TableCell>To</TableCell>
+              <TableCell>Revision</TableCell>
+              <TableCell>Timestamp</TableCell>
+              <TableCell>Author</TableCell>
+              <TableCell>Message</TableCell>
+              <TableCell></TableCell>
+            </TableRow>
+          </TableHead>
+          <TableBody>
+            {revisions.Revisions.map((r, i) => (
+              <StyledTableRow key={r.Hash + i}>
+                <TableCell>
+                  <Radio
+                    size="small"
+                    checked={from == r.Hash}
+                    onChange={() => setFrom(r.Hash)}
+                  />
+                </TableCell>
+                <TableCell>
+                  <Radio
+                    size="small"
+                    checked={to == r.Hash}
+                    onChange={() => setTo(r.Hash)}
+                  />
+                </TableCell>
+                <TableCell>{r.Hash.substring(0, 8)}</TableCell>
+                <TableCell>
+                  {moment(r.Timestamp).format('YYYY-MM-DD HH:mm:ss')}
+                </TableCell>
+                <TableCell>{r.Author}</TableCell>
+                <TableCell>{r.Message}</TableCell>
+                <TableCell>
+                  {i > 0 ? (
+                    <Button
+                      size="small"
+                      variant="outlined"
+                      onClick={() => rollback(r.Hash)}
+                    >
+                      Rollback
+                    </Button>
+                  ) : null}
+                </TableCell>
+              </StyledTableRow>
+            ))}
+          </TableBody>
+        </Table>
+      </BorderedBox>
+      {diff ? (
+        <BorderedBox sx={{ mt: 2, px: 2, py: 1, overflowX: 'auto' }}>
+          <Box sx={{ color: 'grey.600' }}>
+            {diff.From.substring(0, 8)}..{diff.To.substring(0, 8)}
+            {to ? null : ' (current)'}
+          </Box>
+          <pre style={{ margin: 0 }}>{diff.Diff || 'No changes'}</pre>
+        </BorderedBox>
+      ) : null}
+    </React.Fragment>
+  );
+}
+
+export default RevisionTable;
@@ -0,0 +1,40 @@
+import { ToggleButton, ToggleButtonGroup } from '@mui/material';
+import React from 'react';
+import { Status } from '../../models';
+
+type Props = {
+  runs: Status[];
+  value: string;
+  onChange: (value: string) => void;
+};
+
+// RunSwitch selects one of the running runs of the DAG to show its status.
+// The empty value is the latest run.
+function RunSwitch({ runs, value, onChange }: Props) {
+  return (
+    <ToggleButtonGroup
+      value={value}
+      exclusive
+      size="small"
+      onChange={(_, value) => {
+        if (value !== null) {
+          onChange(value as string);
+        }
+      }}
+    >
+      <ToggleButton value="" aria-label="latest">
+        Latest
+      </ToggleButton>
+      {runs.map((run) => (
+        <ToggleButton
+          key={run.RequestId}
+          value={run.RequestId}
+          aria-label={run.RequestId}
+        >
+          {run.RequestId.substring(0, 8)}
+        </ToggleButton>
+      ))}
+    </ToggleButtonGroup>
+  );
+}
+export default RunSwitch;
@@ -8,6 +8,7 @@ import DAGAttributes from '../molecules/DAGAttributes';
 import DAGDefinition from '../molecules/DAGDefinition';
 import Graph, { FlowchartType } from '../molecules/Graph';
 import DAGStepTable from '../molecules/DAGStepTable';
+import RevisionTable from '../molecules/RevisionTable';
 import BorderedBox from '../atoms/BorderedBox';
 import SubTitle from '../atoms/SubTitle';
 import FlowchartSwitch from '../molecules/FlowchartSwitch';
@@ -131,10 +132,15 @@ function DAGSpec({ data }: Props) {
                           </span>
                         }
                         onClick={async () => {
+                          const message = prompt('Describe the change');
+                          if (message === null) {
+                            return;
+                          }
                           const formData = new FormData();
                           formData.append('action', 'save');
                           formData.append('value', currentValue);
-                          const url = `${API_URL}/dags/${props.name}`;
+                          formData.append('message', message);
+                          const url = `${API_URL}/dags/${encodeURIComponent(props.name)}`;
                           const resp = await fetch(url, {
                             method: 'POST',
                             headers: {
@@ -199,6 +205,17 @@ function DAGSpec({ data }: Props) {
                 )}
               </BorderedBox>
             </Box>
+
+            <Box sx={{ mt: 3 }}>
+              <SubTitle>Revisions</SubTitle>
+              <Box sx={{ mt: 2 }}>
+                <RevisionTable
+                  name={props.name}
+                  definition={data.Definition}
+                  refresh={props.refresh}
+                />
+              </Box>
+            </Box>
           </React.Fragment>
         )
       }
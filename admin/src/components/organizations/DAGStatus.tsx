@@ -1,4 +1,5 @@
 import React from 'react';
+import { useSearchParams } from 'react-router-dom';
 import { DAGContext } from '../../contexts/DAGContext';
 import { DAGStatus } from '../../models';
 import { Handlers, SchedulerStatus } from '../../models';
@@ -7,7 +8,10 @@ import NodeStatusTable from '../molecules/NodeStatusTable';
 import DAGStatusOverview from '../molecules/DAGStatusOverview';
 import TimelineChart from '../molecules/TimelineChart';
 import { useDAGPostAPI } from '../../hooks/useDAGPostAPI';
+import { useStatusStream } from '../../hooks/useEventSource';
 import StatusUpdateModal from '../molecules/StatusUpdateModal';
+import QueueTable from '../molecules/QueueTable';
+import RunSwitch from '../molecules/RunSwitch';
 import { Step } from '../../models';
 import { Box, Stack, Tab, Tabs } from '@mui/material';
 import SubTitle from '../atoms/SubTitle';
@@ -23,7 +27,16 @@ type Props = {
   refresh: () => void;
 };
 
-function DAGStatus({ DAG, name, refresh }: Props) {
+function DAGStatus({ DAG: dag, name, refresh }: Props) {
+  // the run to show is given by the query so that it can be linked to
+  const [searchParams, setSearchParams] = useSearchParams();
+  const requestId = searchParams.get('requestId') || '';
+  const setRequestId = React.useCallback(
+    (value: string) => setSearchParams(value ? { requestId: value } : {}),
+    [setSearchParams]
+  );
+  const live = useStatusStream(name, requestId);
+  const DAG = live ? { ...dag, Status: live } : dag;
   const [modal, setModal] = React.useState(false);
   const [sub, setSub] = React.useState('0');
   const [selectedStep, setSelectedStep] = React.useState<Step | undefined>(
@@ -81,7 +94,16 @@ function DAGStatus({ DAG, name, refresh }: Props) {
       <Box>
         <Stack direction="row" justifyContent="space-between">
           <SubTitle>Overview</SubTitle>
-          <FlowchartSwitch value={flowchart} onChange={onChangeFlowchart} />
+          <Stack direction="row" spacing={2}>
+            {(dag.Running?.length || 0) > 1 || requestId ? (
+              <RunSwitch
+                runs={dag.Running || []}
+                value={requestId}
+                onChange={setRequestId}
+              />
+            ) : null}
+            <FlowchartSwitch value={flowchart} onChange={onChangeFlowchart} />
+          </Stack>
         </Stack>
         <BorderedBox
           sx={{
@@ -159,6 +181,15 @@ function DAGStatus({ DAG, name, refresh }: Props) {
                 </Box>
               </Box>
 
+              {DAG.Queue?.length ? (
+                <Box sx={{ mt: 3 }}>
+                  <SubTitle>Queued Runs</SubTitle>
+                  <Box sx={{ mt: 2 }}>
+                    <QueueTable queue={DAG.Queue}></QueueTable>
+                  </Box>
+                </Box>
+              ) : null}
+
               {handlers?.length ? (
                 <Box sx={{ mt: 3 }}>
                   <SubTitle>Lifecycle Hooks</SubTitle>
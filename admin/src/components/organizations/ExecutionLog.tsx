@@ -1,6 +1,9 @@
 import { Box, Stack } from '@mui/material';
 import React from 'react';
 import { LogFile } from '../../models/api';
+import { SchedulerStatus } from '../../models';
+import { DAGContext } from '../../contexts/DAGContext';
+import { useLogStream } from '../../hooks/useEventSource';
 import BorderedBox from '../atoms/BorderedBox';
 import LabeledItem from '../atoms/LabeledItem';
 import LoadingIndicator from '../atoms/LoadingIndicator';
@@ -11,6 +14,18 @@ type Props = {
 };
 
 function ExecutionLog({ log }: Props) {
+  const { data, name } = React.useContext(DAGContext);
+  const running =
+    data?.DAG?.Status?.Status == SchedulerStatus.Running &&
+    data.DAG.Status.RequestId == log?.RequestId;
+  let url: string | undefined;
+  if (log && running) {
+    url = `${API_URL}/api/v1/dags/${encodeURIComponent(name)}/runs/${log.RequestId}`;
+    url += log.Step
+      ? `/steps/${encodeURIComponent(log.Step.Step.Name)}/log/stream`
+      : '/log/stream';
+  }
+  const content = useLogStream(url);
   if (!log) {
     return <LoadingIndicator />;
   }
@@ -52,7 +67,7 @@ function ExecutionLog({ log }: Props) {
             fontFamily: 'Courier New, Courier, monospace',
           }}
         >
-          {log.Content || '<No log output>'}
+          {content ?? (log.Content || '<No log output>')}
         </pre>
       </BorderedBox>
     </Box>
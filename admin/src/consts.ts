@@ -21,6 +21,8 @@ export const nodeStatusColorMapping = {
   [NodeStatus.Cancel]: statusColorMapping[SchedulerStatus.Cancel],
   [NodeStatus.Success]: statusColorMapping[SchedulerStatus.Success],
   [NodeStatus.Skipped]: statusColorMapping[SchedulerStatus.Skipped_Unused],
+  [NodeStatus.Timeout]: { backgroundColor: 'orange', color: 'white' },
+  [NodeStatus.Queued]: { backgroundColor: 'khaki' },
 };
 
 export const stepTabColStyles = [
@@ -18,7 +18,7 @@ export function useDAGPostAPI(opts: Options) {
       if (step) {
         form.set('step', step);
       }
-      const url = `${API_URL}/dags/${opts.name}`;
+      const url = `${API_URL}/dags/${encodeURIComponent(opts.name)}`;
       const ret = await fetch(url, {
         method: 'POST',
         mode: 'cors',
@@ -0,0 +1,48 @@
+import React from 'react';
+import { Status } from '../models';
+
+type LogChunk = {
+  Offset: number;
+  Content: string;
+};
+
+// useStatusStream returns the latest status of the DAG pushed by the server,
+// or the status of the run of the request ID if given.
+export function useStatusStream(name: string, requestId?: string) {
+  const [status, setStatus] = React.useState<Status>();
+  React.useEffect(() => {
+    setStatus(undefined);
+    const query = requestId
+      ? `?requestId=${encodeURIComponent(requestId)}`
+      : '';
+    const es = new EventSource(
+      `${API_URL}/api/v1/dags/${encodeURIComponent(name)}/status/stream${query}`
+    );
+    es.addEventListener('status', (e) => {
+      setStatus(JSON.parse((e as MessageEvent).data));
+    });
+    return () => es.close();
+  }, [name, requestId]);
+  return status;
+}
+
+// useLogStream returns the content of the log file streamed from the url
+// while it grows. It returns undefined until the first chunk arrives.
+export function useLogStream(url?: string) {
+  const [content, setContent] = React.useState<string>();
+  React.useEffect(() => {
+    setContent(undefined);
+    if (!url) {
+      return;
+    }
+    const es = new EventSource(url);
+    es.addEventListener('log', (e) => {
+      const chunk: LogChunk = JSON.parse((e as MessageEvent).data);
+      setContent((c) => (c || '') + chunk.Content);
+    });
+    es.addEventListener('end', () => es.close());
+    es.addEventListener('error', () => es.close());
+    return () => es.close();
+  }, [url]);
+  return content;
+}
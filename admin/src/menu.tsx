@@ -7,6 +7,7 @@ import {
   TimelineOutlined,
   TocOutlined,
   SearchOutlined,
+  HistoryOutlined,
 } from '@mui/icons-material';
 import { Typography } from '@mui/material';
 
@@ -21,6 +22,9 @@ export const mainListItems = (
     <Link to="/search">
       <ListItem text="Search" icon={<SearchOutlined />} />
     </Link>
+    <Link to="/audit">
+      <ListItem text="Audit Log" icon={<HistoryOutlined />} />
+    </Link>
   </React.Fragment>
 );
 
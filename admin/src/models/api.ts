@@ -39,11 +39,13 @@ export type LogFile = {
   Step?: Node;
   LogFile: string;
   Content: string;
+  RequestId: string;
 };
 
 export type GridData = {
   Name: string;
   Vals: NodeStatus[];
+  BackfillId?: string;
 };
 
 export type GetDAGsResponse = {
@@ -53,3 +55,34 @@ export type GetDAGsResponse = {
   Errors: string[];
   HasError: boolean;
 };
+
+export type GetAuditResponse = {
+  Entries: AuditEntry[];
+};
+
+export type GetRevisionsResponse = {
+  Revisions: Revision[];
+};
+
+export type Revision = {
+  Hash: string;
+  Author: string;
+  Message: string;
+  Timestamp: string;
+};
+
+export type RevisionDiff = {
+  From: string;
+  To: string;
+  Diff: string;
+};
+
+export type AuditEntry = {
+  Timestamp: string;
+  RemoteAddr: string;
+  User: string;
+  DAG: string;
+  Action: string;
+  Params?: { [key: string]: string };
+  Diff?: string;
+};
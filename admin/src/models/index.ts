@@ -24,6 +24,13 @@ export type Status = {
   FinishedAt: string;
   Log: string;
   Params: string;
+  Parent?: string;
+  ParentRequestId?: string;
+  Outputs?: { [key: string]: string };
+  Artifacts?: string[];
+  Revision?: string;
+  ScheduledTime?: string;
+  BackfillId?: string;
 };
 
 export function Handlers(s: Status) {
@@ -46,8 +53,28 @@ export function Handlers(s: Status) {
 export type Condition = {
   Condition: string;
   Expected: string;
+  Not: boolean;
+  FileExists: string;
+  Command: string;
+  ExitCode: number;
+  All?: Condition[];
+  Any?: Condition[];
 };
 
+export function conditionText(c: Condition): string {
+  let text = `${c.Condition} => ${c.Expected}`;
+  if (c.All) {
+    text = `all(${c.All.map(conditionText).join(', ')})`;
+  } else if (c.Any) {
+    text = `any(${c.Any.map(conditionText).join(', ')})`;
+  } else if (c.FileExists) {
+    text = `file exists: ${c.FileExists}`;
+  } else if (c.Command) {
+    text = `${c.Command} => exit ${c.ExitCode}`;
+  }
+  return c.Not ? `not ${text}` : text;
+}
+
 export type DAG = {
   Location: string;
   Name: string;
@@ -66,10 +93,13 @@ export type DAG = {
   DefaultParams: string;
   Delay: number;
   MaxCleanUpTime: number;
+  Timezone?: string;
+  OverlapPolicy?: string;
 };
 
 export type Schedule = {
   Expression: string;
+  Timezone?: string;
 };
 
 export type HandlerOn = {
@@ -86,6 +116,16 @@ export type DAGStatus = {
   Status?: Status;
   Suspended: boolean;
   ErrorT: string;
+  Queue?: QueuedRun[];
+  Running?: Status[];
+};
+
+export type QueuedRun = {
+  RequestId: string;
+  Params: string;
+  ScheduledTime?: string;
+  BackfillId?: string;
+  QueuedAt: string;
 };
 
 export enum DAGDataType {
@@ -145,15 +185,83 @@ export function getStatusField(
 }
 
 export function getNextSchedule(data: DAGStatus): number {
+  const next = nextRun(data);
+  return next ? next.time : Number.MAX_SAFE_INTEGER;
+}
+
+export function getNextScheduleTimezone(data: DAGStatus): string {
+  const next = nextRun(data);
+  return next?.timezone || '';
+}
+
+function nextRun(data: DAGStatus): { time: number; timezone: string } | null {
   const schedules = data.DAG.Schedule;
   if (!schedules || schedules.length == 0 || data.Suspended) {
+    return null;
+  }
+  const datesToRun = schedules.map((s) => ({
+    time: nextScheduleTime(cronExpression(s.Expression), s.Timezone),
+    timezone: s.Timezone || '',
+  }));
+  const sorted = datesToRun.sort((a, b) => a.time - b.time);
+  return sorted[0];
+}
+
+const descriptors: { [key: string]: string } = {
+  '@yearly': '0 0 1 1 *',
+  '@annually': '0 0 1 1 *',
+  '@monthly': '0 0 1 * *',
+  '@weekly': '0 0 * * 0',
+  '@daily': '0 0 * * *',
+  '@midnight': '0 0 * * *',
+  '@hourly': '0 * * * *',
+};
+
+// nextScheduleTime returns the next time of the schedule in unix seconds.
+// The next time of an @every schedule is approximated from now.
+function nextScheduleTime(expr: string, tz?: string): number {
+  const every = expr.match(/^@every\s+(\S+)$/);
+  if (every) {
+    const sec = parseDuration(every[1]);
+    return sec > 0
+      ? Math.ceil(Date.now() / 1000 + sec)
+      : Number.MAX_SAFE_INTEGER;
+  }
+  try {
+    return (
+      cronParser
+        .parseExpression(descriptors[expr] || expr, { tz: tz || undefined })
+        .next()
+        .getTime() / 1000
+    );
+  } catch (e) {
     return Number.MAX_SAFE_INTEGER;
   }
-  const datesToRun = schedules.map((s) =>
-    cronParser.parseExpression(s.Expression).next()
-  );
-  const sorted = datesToRun.sort((a, b) => a.getTime() - b.getTime());
-  return sorted[0].getTime() / 1000;
+}
+
+const durationUnits: { [key: string]: number } = {
+  h: 3600,
+  m: 60,
+  s: 1,
+  ms: 0.001,
+};
+
+// parseDuration parses a Go duration such as "1h30m" into seconds.
+function parseDuration(val: string): number {
+  const re = /(\d+(?:\.\d+)?)(h|ms|m|s)/g;
+  let sec = 0;
+  let len = 0;
+  let m;
+  while ((m = re.exec(val)) !== null) {
+    sec += parseFloat(m[1]) * durationUnits[m[2]];
+    len += m[0].length;
+  }
+  return len == val.length ? sec : 0;
+}
+
+// cronExpression removes the CRON_TZ or TZ prefix from the expression.
+function cronExpression(expr: string): string {
+  return expr.replace(/^(CRON_)?TZ=\S+\s+/, '');
 }
 
 export enum NodeStatus {
@@ -163,6 +271,8 @@ export enum NodeStatus {
   Cancel,
   Success,
   Skipped,
+  Timeout,
+  Queued,
 }
 
 export type Node = {
@@ -175,8 +285,16 @@ export type Node = {
   DoneCount: number;
   Error: string;
   StatusText: string;
+  ChildRequestId?: string;
+  ParallelParent?: string;
+  SkipReason?: string;
 };
 
+export function dagNameFromFile(file: string) {
+  const base = file.split('/').pop() || '';
+  return base.replace(/.y[a]{0,1}ml$/, '');
+}
+
 export type StatusFile = {
   File: string;
   Status: Status;
@@ -199,10 +317,27 @@ export type Step = {
   RepeatPolicy: RepeatPolicy;
   MailOnError: boolean;
   Preconditions: Condition[];
+  Run?: string;
+  Params?: string;
+  Parallel?: ParallelConfig;
+  Timeout: number;
+  When?: Condition[];
+  Pool?: string;
+};
+
+export type ParallelConfig = {
+  Items?: string[];
+  Variable: string;
+  MaxConcurrent: number;
 };
 
 export type RetryPolicy = {
   Limit: number;
+  Interval: number;
+  Backoff: number;
+  MaxInterval: number;
+  Jitter: number;
+  ExitCodes?: number[];
 };
 
 export type RepeatPolicy = {
@@ -0,0 +1,70 @@
+import React from 'react';
+import { Box, Button, Stack, TextField } from '@mui/material';
+import useSWR from 'swr';
+import { useSearchParams } from 'react-router-dom';
+import Title from '../../components/atoms/Title';
+import LoadingIndicator from '../../components/atoms/LoadingIndicator';
+import AuditTable from '../../components/molecules/AuditTable';
+import { GetAuditResponse } from '../../models/api';
+import { AppBarContext } from '../../contexts/AppBarContext';
+
+const filterKeys = ['dag', 'user', 'action', 'since', 'until'];
+
+function Audit() {
+  const [searchParams, setSearchParams] = useSearchParams();
+  const [filter, setFilter] = React.useState<Record<string, string>>(() =>
+    Object.fromEntries(filterKeys.map((k) => [k, searchParams.get(k) || '']))
+  );
+  const appBarContext = React.useContext(AppBarContext);
+
+  const { data, error } = useSWR<GetAuditResponse>(
+    `/audit?${searchParams.toString()}`
+  );
+
+  React.useEffect(() => {
+    appBarContext.setTitle('Audit Log');
+  }, [appBarContext]);
+
+  const onSubmit = React.useCallback(() => {
+    setSearchParams(
+      Object.fromEntries(Object.entries(filter).filter(([, v]) => v != ''))
+    );
+  }, [filter]);
+
+  return (
+    <Box sx={{ mx: 4, width: '100%' }}>
+      <Title>Audit Log</Title>
+      <Stack spacing={2} direction="row">
+        {filterKeys.map((k) => (
+          <TextField
+            key={k}
+            label={k}
+            size="small"
+            placeholder={k == 'since' || k == 'until' ? 'YYYY-MM-DD' : ''}
+            value={filter[k]}
+            onChange={(e) => {
+              setFilter({ ...filter, [k]: e.target.value });
+            }}
+            onKeyDown={(e) => {
+              if (e.key === 'Enter') {
+                onSubmit();
+              }
+            }}
+          />
+        ))}
+        <Button variant="contained" sx={{ border: 0 }} onClick={onSubmit}>
+          Filter
+        </Button>
+      </Stack>
+      <Box mt={2}>
+        {!data && !error ? <LoadingIndicator /> : null}
+        {error ? <Box>{error.message}</Box> : null}
+        {data && data.Entries.length == 0 ? <Box>No entries found</Box> : null}
+        {data && data.Entries.length > 0 ? (
+          <AuditTable entries={data.Entries} />
+        ) : null}
+      </Box>
+    </Box>
+  );
+}
+export default Audit;
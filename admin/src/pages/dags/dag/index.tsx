@@ -24,7 +24,7 @@ function DAGDetails() {
   const appBarContext = React.useContext(AppBarContext);
   const path = useLocation().pathname;
   const baseUrl = useMemo(
-    () => `/dags/${encodeURI(params.name!)}`,
+    () => `/dags/${encodeURIComponent(params.name!)}`,
     [params.name]
   );
   const { data, isValidating } = useSWR<GetDAGResponse>(
@@ -77,6 +77,7 @@ function DAGDetails() {
           <Title>{data.Title}</Title>
           <DAGActions
             status={data.DAG.Status}
+            overlapPolicy={data.DAG.DAG.OverlapPolicy}
             name={params.name!}
             refresh={refreshFn}
             redirectTo={`${baseUrl}`}
@@ -9,6 +9,8 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,8 +22,13 @@ import (
 	"github.com/yohamta/dagu/internal/logger"
 	"github.com/yohamta/dagu/internal/mailer"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/pool"
+	"github.com/yohamta/dagu/internal/queue"
 	"github.com/yohamta/dagu/internal/reporter"
+	"github.com/yohamta/dagu/internal/revision"
 	"github.com/yohamta/dagu/internal/scheduler"
+	// registers the executor of the steps waiting for another DAG
+	_ "github.com/yohamta/dagu/internal/sensor"
 	"github.com/yohamta/dagu/internal/sock"
 	"github.com/yohamta/dagu/internal/utils"
 )
@@ -41,11 +48,30 @@ type Agent struct {
 	dbWriter     *database.Writer
 	socketServer *sock.Server
 	requestId    string
+	artifactsDir string
+	// artifacts are the files in the artifacts directory listed when the
+	// run has finished.
+	artifacts     []string
+	artifactsLock sync.Mutex
+	revision      string
 }
 
 type AgentConfig struct {
 	DAG *dag.DAG
 	Dry bool
+	// RequestId is the request ID of the run. A new one is generated when empty.
+	RequestId string
+	// Parent and ParentRequestId identify the parent run when the DAG
+	// runs as a sub-DAG of another DAG.
+	Parent          string
+	ParentRequestId string
+	// ScheduledTime is the time the run is scheduled at when it is started
+	// by the scheduler. It is given to the steps as DAGU_SCHEDULED_TIME.
+	ScheduledTime time.Time
+	// BackfillId is the ID of the backfill when the run is a part of it.
+	BackfillId string
+	// Pools are the pools defined in the admin config.
+	Pools []*pool.Pool
 }
 
 type RetryConfig struct {
@@ -57,6 +83,9 @@ func (a *Agent) Run() error {
 	if err := a.setupRequestId(); err != nil {
 		return err
 	}
+	if err := a.setupScheduledTime(); err != nil {
+		return err
+	}
 	a.init()
 	if err := a.setupGraph(); err != nil {
 		return err
@@ -64,17 +93,24 @@ func (a *Agent) Run() error {
 	if err := a.checkPreconditions(); err != nil {
 		return err
 	}
+	if err := a.checkPools(); err != nil {
+		return err
+	}
 	if a.Dry {
 		return a.dryRun()
 	}
 	setup := []func() error{
 		a.checkIsRunning,
 		a.setupDatabase,
+		a.setupArtifactsDir,
 		a.setupSocketServer,
 		a.setupLogFile,
 	}
 	for _, fn := range setup {
 		err := fn()
+		if errors.Is(err, errQueued) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -94,6 +130,17 @@ func (a *Agent) Status() *models.Status {
 	)
 	status.RequestId = a.requestId
 	status.Log = a.logFilename
+	status.Parent = a.Parent
+	status.ParentRequestId = a.ParentRequestId
+	status.Outputs = a.graph.Outputs()
+	status.Revision = a.revision
+	if !a.ScheduledTime.IsZero() {
+		status.ScheduledTime = utils.FormatTime(a.ScheduledTime)
+	}
+	status.BackfillId = a.BackfillId
+	a.artifactsLock.Lock()
+	status.Artifacts = a.artifacts
+	a.artifactsLock.Unlock()
 	if node := a.scheduler.HandlerNode(constants.OnExit); node != nil {
 		status.OnExit = models.FromNode(node)
 	}
@@ -150,6 +197,7 @@ func (a *Agent) signal(sig os.Signal, allowOverride bool) {
 }
 
 func (a *Agent) init() {
+	a.revision = revision.HashFile(a.DAG.Location)
 	logDir := path.Join(a.DAG.LogDir, utils.ValidFilename(a.DAG.Name, "_"))
 	a.scheduler = &scheduler.Scheduler{
 		Config: &scheduler.Config{
@@ -162,7 +210,15 @@ func (a *Agent) init() {
 			OnFailure:     a.DAG.HandlerOn.Failure,
 			OnCancel:      a.DAG.HandlerOn.Cancel,
 			RequestId:     a.requestId,
+			DAGLocation:   a.DAG.Location,
+			ScheduledTime: a.ScheduledTime,
+			Pools:         map[string]*pool.Pool{},
+
+			TimeoutGracePeriod: a.DAG.MaxCleanUpTime,
 		}}
+	for _, p := range a.Pools {
+		a.scheduler.Pools[p.Name] = p
+	}
 	a.reporter = &reporter.Reporter{
 		Config: &reporter.Config{
 			Mailer: &mailer.Mailer{
@@ -206,11 +262,15 @@ func (a *Agent) setupRetry() (err error) {
 	for _, n := range a.RetryConfig.Status.Nodes {
 		nodes = append(nodes, n.ToNode())
 	}
-	a.graph, err = scheduler.NewExecutionGraphForRetry(nodes...)
+	a.graph, err = scheduler.NewExecutionGraphForRetry(a.RetryConfig.Status.Outputs, nodes...)
 	return
 }
 
 func (a *Agent) setupRequestId() error {
+	if a.AgentConfig.RequestId != "" {
+		a.requestId = a.AgentConfig.RequestId
+		return nil
+	}
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return err
@@ -219,6 +279,15 @@ func (a *Agent) setupRequestId() error {
 	return nil
 }
 
+// setupScheduledTime sets DAGU_SCHEDULED_TIME when the run is started by
+// the scheduler.
+func (a *Agent) setupScheduledTime() error {
+	if a.ScheduledTime.IsZero() {
+		return nil
+	}
+	return os.Setenv(constants.ScheduledTimeEnv, a.ScheduledTime.Format(time.RFC3339))
+}
+
 func (a *Agent) setupDatabase() (err error) {
 	a.database = &database.Database{
 		Config: database.DefaultConfig(),
@@ -229,10 +298,32 @@ func (a *Agent) setupDatabase() (err error) {
 	return
 }
 
+// setupArtifactsDir creates the directory to store the artifacts of the run.
+// The artifacts of the previous run are copied to it on retry.
+func (a *Agent) setupArtifactsDir() error {
+	a.artifactsDir = a.database.ArtifactsDir(a.DAG.Location, a.requestId)
+	if err := os.MkdirAll(a.artifactsDir, 0755); err != nil {
+		return err
+	}
+	if a.RetryConfig != nil && a.RetryConfig.Status != nil {
+		prev := a.database.ArtifactsDir(a.DAG.Location, a.RetryConfig.Status.RequestId)
+		if prev != a.artifactsDir && utils.FileExists(prev) {
+			if err := utils.CopyDir(prev, a.artifactsDir); err != nil {
+				return err
+			}
+		}
+	}
+	a.scheduler.ArtifactsDir = a.artifactsDir
+	return nil
+}
+
 func (a *Agent) setupSocketServer() (err error) {
+	if err := os.MkdirAll(a.DAG.SockDir(), 0755); err != nil {
+		return err
+	}
 	a.socketServer, err = sock.NewServer(
 		&sock.Config{
-			Addr:        a.DAG.SockAddr(),
+			Addr:        a.DAG.SockAddr(a.requestId),
 			HandlerFunc: a.handleHTTP,
 		})
 	return
@@ -249,6 +340,16 @@ func (a *Agent) checkPreconditions() error {
 	return nil
 }
 
+// checkPools checks that the pools of the steps are defined.
+func (a *Agent) checkPools() error {
+	for _, s := range a.DAG.Steps {
+		if _, ok := a.scheduler.Pools[s.Pool]; s.Pool != "" && !ok {
+			return fmt.Errorf("pool %s of step %s is not defined", s.Pool, s.Name)
+		}
+	}
+	return nil
+}
+
 func (a *Agent) run() error {
 	tl := &logger.TeeLogger{Writer: a.logFile}
 	if err := tl.Open(); err != nil {
@@ -304,10 +405,15 @@ func (a *Agent) run() error {
 	}()
 
 	lastErr := a.scheduler.Schedule(a.graph, done)
+	if a.artifactsDir != "" {
+		a.artifactsLock.Lock()
+		a.artifacts = database.ListArtifacts(a.artifactsDir)
+		a.artifactsLock.Unlock()
+	}
 	status := a.Status()
 
 	log.Println("schedule finished.")
-	utils.LogErr("write status", a.dbWriter.Write(a.Status()))
+	utils.LogErr("write status", a.dbWriter.Write(status))
 
 	a.reporter.ReportSummary(status, lastErr)
 	utils.LogErr("send email", a.reporter.SendMail(a.DAG, status, lastErr))
@@ -342,16 +448,75 @@ func (a *Agent) dryRun() error {
 	return lastErr
 }
 
+// errQueued is returned by checkIsRunning when the run is queued.
+var errQueued = errors.New("the run is queued")
+
+// checkIsRunning checks that the DAG is not running. When it is running,
+// the run is queued or the running one is stopped by the overlap policy.
 func (a *Agent) checkIsRunning() error {
-	status, err := controller.NewDAGController(a.DAG).GetStatus()
+	if a.DAG.OverlapPolicy == dag.OverlapAllowParallel {
+		return nil
+	}
+	c := controller.NewDAGController(a.DAG)
+	status, err := c.GetStatus()
 	if err != nil {
 		return err
 	}
-	if status.Status != scheduler.SchedulerStatus_None {
-		return fmt.Errorf("the DAG is already running. socket=%s",
-			a.DAG.SockAddr())
+	if status.Status == scheduler.SchedulerStatus_None {
+		return nil
+	}
+	switch {
+	case a.DAG.OverlapPolicy == dag.OverlapCancelPrevious:
+		return a.cancelPrevious(c)
+	case a.DAG.OverlapPolicy == dag.OverlapQueue && a.Parent == "" && a.RetryConfig == nil:
+		// a sub-DAG is not queued since the parent waits for the run,
+		// and neither is a retry since the queue starts a new run
+		return a.enqueue()
+	}
+	return fmt.Errorf("the DAG is already running. request ID=%s",
+		status.RequestId)
+}
+
+// enqueue adds the run to the queue of the DAG, which is started by the
+// scheduler after the running one finishes.
+func (a *Agent) enqueue() error {
+	item := &queue.Item{
+		RequestId:  a.requestId,
+		Params:     strings.Join(a.DAG.Params, " "),
+		BackfillId: a.BackfillId,
+	}
+	if !a.ScheduledTime.IsZero() {
+		item.ScheduledTime = a.ScheduledTime.Format(time.RFC3339)
+	}
+	pos, err := queue.New().Push(a.DAG.Location, item, a.DAG.MaxQueueLength)
+	if err != nil {
+		return fmt.Errorf("the DAG is already running and the run is not queued: %w", err)
+	}
+	log.Printf("the DAG is already running. the run %s is queued at position %d", a.requestId, pos)
+	return errQueued
+}
+
+// cancelPrevious stops the running DAG and waits for it to finish.
+func (a *Agent) cancelPrevious(c *controller.DAGController) error {
+	log.Printf("the DAG is already running. stopping it...")
+	if err := c.Stop(); err != nil {
+		return err
+	}
+	timeout := time.After(a.DAG.MaxCleanUpTime + time.Minute)
+	for {
+		status, err := c.GetStatus()
+		if err != nil {
+			return err
+		}
+		if status.Status == scheduler.SchedulerStatus_None {
+			return nil
+		}
+		select {
+		case <-timeout:
+			return fmt.Errorf("the running DAG did not stop")
+		case <-time.After(time.Millisecond * 500):
+		}
 	}
-	return nil
 }
 
 func (a *Agent) closeLogFile() error {
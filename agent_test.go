@@ -10,9 +10,15 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/constants"
 	"github.com/yohamta/dagu/internal/controller"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/database"
+	"github.com/yohamta/dagu/internal/executor"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/pool"
+	"github.com/yohamta/dagu/internal/queue"
+	"github.com/yohamta/dagu/internal/revision"
 	"github.com/yohamta/dagu/internal/scheduler"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/utils"
@@ -21,6 +27,7 @@ import (
 var testdataDir = path.Join(utils.MustGetwd(), "testdata")
 
 func TestMain(m *testing.M) {
+	executor.DAGExecutable = path.Join(utils.MustGetwd(), "bin/dagu")
 	testHomeDir := utils.MustTempDir("agent_test")
 	settings.ChangeHomeDir(testHomeDir)
 	code := m.Run()
@@ -77,6 +84,98 @@ func TestCheckRunning(t *testing.T) {
 	require.Contains(t, err.Error(), "is already running")
 }
 
+func TestOverlapQueue(t *testing.T) {
+	d := testLoadDAG(t, "is_running.yaml")
+	d.OverlapPolicy = dag.OverlapQueue
+	d.MaxQueueLength = 1
+
+	// wait for the run of the previous test to finish
+	require.Eventually(t, func() bool {
+		s, err := controller.NewDAGController(d).GetStatus()
+		return err == nil && s.Status != scheduler.SchedulerStatus_Running
+	}, time.Second*3, time.Millisecond*100)
+
+	a := &Agent{AgentConfig: &AgentConfig{DAG: d}}
+	go func() {
+		a.Run()
+	}()
+	require.Eventually(t, func() bool {
+		return a.Status().Status == scheduler.SchedulerStatus_Running
+	}, time.Second, time.Millisecond*10)
+
+	queued := &Agent{AgentConfig: &AgentConfig{DAG: d, RequestId: "queued"}}
+	require.NoError(t, queued.Run())
+
+	items, err := queue.New().List(d.Location)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "queued", items[0].RequestId)
+
+	// the queue is full
+	_, err = testDAG(t, d)
+	require.ErrorIs(t, err, queue.ErrQueueFull)
+
+	require.NoError(t, queue.New().Remove(d.Location, "queued"))
+	require.Eventually(t, func() bool {
+		return a.Status().Status == scheduler.SchedulerStatus_Success
+	}, time.Second*3, time.Millisecond*100)
+}
+
+func TestOverlapCancelPrevious(t *testing.T) {
+	d := testLoadDAG(t, "cancel_previous.yaml")
+	d.OverlapPolicy = dag.OverlapCancelPrevious
+
+	a := &Agent{AgentConfig: &AgentConfig{DAG: d, RequestId: "previous"}}
+	go func() {
+		a.Run()
+	}()
+	require.Eventually(t, func() bool {
+		return a.Status().Status == scheduler.SchedulerStatus_Running
+	}, time.Second, time.Millisecond*10)
+
+	d.Steps[0].CmdWithArgs = "true"
+	status, err := testDAG(t, d)
+	require.NoError(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Success, status.Status)
+
+	prev, err := controller.NewDAGController(d).GetStatusByRequestId("previous")
+	require.NoError(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Cancel, prev.Status)
+}
+
+func TestOverlapAllowParallel(t *testing.T) {
+	d := testLoadDAG(t, "allow_parallel.yaml")
+	c := controller.NewDAGController(d)
+
+	for _, requestId := range []string{"run-1", "run-2"} {
+		a := &Agent{AgentConfig: &AgentConfig{DAG: d, RequestId: requestId}}
+		go func() {
+			a.Run()
+		}()
+	}
+	require.Eventually(t, func() bool {
+		running, err := c.GetRunningStatuses()
+		return err == nil && len(running) == 2
+	}, time.Second, time.Millisecond*10)
+
+	for _, requestId := range []string{"run-1", "run-2"} {
+		s, err := c.GetStatusByRequestId(requestId)
+		require.NoError(t, err)
+		require.Equal(t, scheduler.SchedulerStatus_Running, s.Status)
+	}
+
+	require.NoError(t, c.StopRun("run-1"))
+	require.Eventually(t, func() bool {
+		s, err := c.GetStatusByRequestId("run-1")
+		return err == nil && s.Status == scheduler.SchedulerStatus_Cancel
+	}, time.Second*3, time.Millisecond*100)
+
+	require.Eventually(t, func() bool {
+		s, err := c.GetStatusByRequestId("run-2")
+		return err == nil && s.Status == scheduler.SchedulerStatus_Success
+	}, time.Second*3, time.Millisecond*100)
+}
+
 func TestDryRun(t *testing.T) {
 	a := &Agent{AgentConfig: &AgentConfig{
 		DAG: testLoadDAG(t, "dry.yaml"),
@@ -140,6 +239,22 @@ func TestPreConditionValid(t *testing.T) {
 	}
 }
 
+func TestPools(t *testing.T) {
+	d := testLoadDAG(t, "multiple_steps.yaml")
+	d.Steps[0].Pool = "warehouse"
+
+	// the pool is not defined
+	_, err := testDAG(t, d)
+	require.Error(t, err)
+
+	a := &Agent{AgentConfig: &AgentConfig{
+		DAG:   d,
+		Pools: []*pool.Pool{pool.New("warehouse", 1)},
+	}}
+	require.NoError(t, a.Run())
+	require.Equal(t, scheduler.SchedulerStatus_Success, a.Status().Status)
+}
+
 func TestStartError(t *testing.T) {
 	d := testLoadDAG(t, "error.yaml")
 	status, err := testDAG(t, d)
@@ -191,6 +306,119 @@ func TestRetry(t *testing.T) {
 	}
 }
 
+func TestRetryWithOutputs(t *testing.T) {
+	d := testLoadDAG(t, "retry_outputs.yaml")
+
+	status, err := testDAG(t, d)
+	require.Error(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Error, status.Status)
+
+	status.Nodes[1].CmdWithArgs = "echo ${RESULT.id}"
+	a := &Agent{
+		AgentConfig: &AgentConfig{
+			DAG: d,
+		},
+		RetryConfig: &RetryConfig{
+			Status: status,
+		},
+	}
+	err = a.Run()
+	require.NoError(t, err)
+
+	status = a.Status()
+	require.Equal(t, scheduler.SchedulerStatus_Success, status.Status)
+	require.Equal(t, map[string]string{
+		"RESULT": `{"id":"a1"}`,
+		"ID":     "a1",
+	}, status.Outputs)
+}
+
+func TestArtifacts(t *testing.T) {
+	d := testLoadDAG(t, "artifacts.yaml")
+
+	status, err := testDAG(t, d)
+	require.Error(t, err)
+	require.Equal(t, []string{"out.txt"}, status.Artifacts)
+	// the directory is given to the steps without changing the environment
+	require.Empty(t, os.Getenv(constants.ArtifactsDirEnv))
+
+	db := &database.Database{Config: database.DefaultConfig()}
+	b, err := os.ReadFile(path.Join(db.ArtifactsDir(d.Location, status.RequestId), "out.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(b))
+
+	// the artifacts of the previous run are available on retry
+	status.Nodes[1].CmdWithArgs = "test -f $DAGU_ARTIFACTS_DIR/out.txt"
+	a := &Agent{
+		AgentConfig: &AgentConfig{
+			DAG: d,
+		},
+		RetryConfig: &RetryConfig{
+			Status: status,
+		},
+	}
+	err = a.Run()
+	require.NoError(t, err)
+
+	status = a.Status()
+	require.Equal(t, scheduler.SchedulerStatus_Success, status.Status)
+	require.Equal(t, []string{"out.txt"}, status.Artifacts)
+}
+
+func TestRevision(t *testing.T) {
+	d := testLoadDAG(t, "outputs.yaml")
+
+	status, err := testDAG(t, d)
+	require.NoError(t, err)
+	require.NotEmpty(t, status.Revision)
+	require.Equal(t, revision.HashFile(d.Location), status.Revision)
+}
+
+func TestSubDAG(t *testing.T) {
+	d := testLoadDAG(t, "sub_dag.yaml")
+
+	status, err := testDAG(t, d)
+	require.NoError(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Success, status.Status)
+
+	childId := status.Nodes[0].ChildRequestId
+	require.NotEmpty(t, childId)
+
+	db := &database.Database{Config: database.DefaultConfig()}
+	child, err := db.FindByRequestId(path.Join(testdataDir, "sub_dag_child.yaml"), childId)
+	require.NoError(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Success, child.Status.Status)
+	require.Equal(t, d.Location, child.Status.Parent)
+	require.Equal(t, status.RequestId, child.Status.ParentRequestId)
+	require.Equal(t, "CHILD_PARAM", child.Status.Params)
+}
+
+func TestOutputs(t *testing.T) {
+	d := testLoadDAG(t, "outputs.yaml")
+
+	status, err := testDAG(t, d)
+	require.NoError(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Success, status.Status)
+
+	db := &database.Database{Config: database.DefaultConfig()}
+	saved, err := db.FindByRequestId(d.Location, status.RequestId)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"RESULT": `{"items":[{"id":"a1"}]}`,
+		"ID":     "a1",
+	}, saved.Status.Outputs)
+}
+
+func TestOutputsInEnv(t *testing.T) {
+	d := testLoadDAG(t, "outputs_env.yaml")
+
+	status, err := testDAG(t, d)
+	require.NoError(t, err)
+	require.Equal(t, scheduler.SchedulerStatus_Success, status.Status)
+	require.Equal(t, "a1", status.Outputs["ID"])
+	require.Equal(t, "a1", status.Outputs["SCRIPT_ID"])
+}
+
 func TestHandleHTTP(t *testing.T) {
 	d := testLoadDAG(t, "handle_http.yaml")
 
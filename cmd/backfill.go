@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+	"github.com/yohamta/dagu/internal/admin"
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+func newBackfillCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "backfill",
+		Usage: "dagu backfill --from=<date> --to=<date> [--parallel=<n>] <DAG file>",
+		Flags: append(
+			globalFlags,
+			&cli.StringFlag{
+				Name:     "from",
+				Usage:    "first date of the range (YYYY-MM-DD or \"YYYY-MM-DD hh:mm:ss\")",
+				Value:    "",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "to",
+				Usage:    "last date of the range (YYYY-MM-DD or \"YYYY-MM-DD hh:mm:ss\")",
+				Value:    "",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:     "parallel",
+				Usage:    "maximum number of runs at the same time",
+				Value:    1,
+				Required: false,
+			},
+		),
+		Action: func(c *cli.Context) error {
+			cfg, err := loadGlobalConfig(c)
+			if err != nil {
+				return err
+			}
+			d, err := loadDAG(c, c.Args().Get(0), "")
+			if err != nil {
+				return err
+			}
+			from, to, err := parseBackfillRange(c.String("from"), c.String("to"))
+			if err != nil {
+				return err
+			}
+			times := backfillTimes(d, from, to)
+			if len(times) == 0 {
+				return fmt.Errorf("no schedule of %s between %s and %s",
+					d.Name, c.String("from"), c.String("to"))
+			}
+			if c.Int("parallel") < 1 {
+				return fmt.Errorf("parallel must be greater than 0")
+			}
+			if c.Int("parallel") > 1 && d.OverlapPolicy != dag.OverlapAllowParallel {
+				return fmt.Errorf("parallel runs of %s require overlapPolicy: %s",
+					d.Name, dag.OverlapAllowParallel)
+			}
+			return backfill(cfg, d, times, c.Int("parallel"))
+		},
+	}
+}
+
+// parseBackfillRange returns the range of the backfill from the first time
+// to the end of the last one. A date ends at the end of the day.
+func parseBackfillRange(from, to string) (time.Time, time.Time, error) {
+	start, _, err := parseBackfillTime(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, isDate, err := parseBackfillTime(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	} else {
+		end = end.Add(time.Second)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+func parseBackfillTime(val string) (t time.Time, isDate bool, err error) {
+	if t, err = time.ParseInLocation("2006-01-02", val, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err = utils.ParseTime(val); err == nil {
+		return t, false, nil
+	}
+	return t, false, fmt.Errorf("invalid date: %s", val)
+}
+
+// backfillTimes returns the times the DAG is scheduled at from start until
+// before end in order.
+func backfillTimes(d *dag.DAG, start, end time.Time) []time.Time {
+	set := map[time.Time]bool{}
+	for _, s := range d.Schedule {
+		for t := s.Parsed.Next(start.Add(-time.Second)); t.Before(end); t = s.Parsed.Next(t) {
+			set[t] = true
+		}
+	}
+	ret := make([]time.Time, 0, len(set))
+	for t := range set {
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Before(ret[j])
+	})
+	return ret
+}
+
+// backfill runs the DAG once for each time with the time given to the run
+// as DAGU_SCHEDULED_TIME. The runs are grouped under a new backfill ID.
+func backfill(cfg *admin.Config, d *dag.DAG, times []time.Time, parallel int) error {
+	backfillId := uuid.NewString()
+	c := controller.NewDAGController(d)
+	log.Printf("backfill %s: %d runs of %s", backfillId, len(times), d.Name)
+
+	var (
+		mu       sync.Mutex
+		canceled bool
+		failed   int
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, parallel)
+	)
+	listenSignals(func(sig os.Signal) {
+		mu.Lock()
+		canceled = true
+		mu.Unlock()
+		utils.LogErr("stop the running DAG", c.Stop())
+	})
+
+	for _, t := range times {
+		sem <- struct{}{}
+		mu.Lock()
+		if canceled {
+			mu.Unlock()
+			break
+		}
+		mu.Unlock()
+		wg.Add(1)
+		go func(t time.Time) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			requestId := uuid.NewString()
+			startErr := c.StartBackfill(cfg.Command, cfg.WorkDir, requestId, backfillId, t)
+			result := fmt.Sprintf("failed to start: %v", startErr)
+			s, err := c.GetStatusByRequestId(requestId)
+			if err == nil {
+				result = s.StatusText
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil || s.Status != scheduler.SchedulerStatus_Success {
+				failed++
+			}
+			log.Printf("[%s] %s", utils.FormatTime(t), result)
+		}(t)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if canceled {
+		return fmt.Errorf("backfill %s canceled", backfillId)
+	}
+	if failed > 0 {
+		return fmt.Errorf("backfill %s: %d of %d runs failed", backfillId, failed, len(times))
+	}
+	log.Printf("backfill %s finished", backfillId)
+	return nil
+}
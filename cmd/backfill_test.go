@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+)
+
+func Test_backfillCommand(t *testing.T) {
+	bin, err := os.Getwd()
+	require.NoError(t, err)
+	cfg := path.Join(t.TempDir(), "admin.yaml")
+	require.NoError(t, os.WriteFile(cfg,
+		[]byte(fmt.Sprintf("command: %s\n", path.Join(bin, "../bin/dagu"))), 0644))
+
+	app := makeApp()
+	runAppTestOutput(app, appTest{
+		args: []string{"", "backfill", fmt.Sprintf("--config=%s", cfg),
+			"--from=2022-01-01", "--to=2022-01-02", testConfig("backfill.yaml")},
+		errored: false,
+		output: []string{
+			"[2022-01-01 00:00:00] finished",
+			"[2022-01-01 12:00:00] finished",
+			"[2022-01-02 00:00:00] finished",
+			"[2022-01-02 12:00:00] finished",
+		},
+	}, t)
+
+	d, err := (&dag.Loader{}).Load(testConfig("backfill.yaml"), "")
+	require.NoError(t, err)
+	statuses := controller.NewDAGController(d).GetRecentStatuses(4)
+	require.Len(t, statuses, 4)
+	backfillId := statuses[0].Status.BackfillId
+	require.NotEmpty(t, backfillId)
+	scheduled := map[string]string{}
+	for _, s := range statuses {
+		require.Equal(t, backfillId, s.Status.BackfillId)
+		scheduled[s.Status.ScheduledTime] = s.Status.Outputs["SCHEDULED_TIME"]
+	}
+	for i := 0; i < 4; i++ {
+		tm := time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local).Add(time.Hour * 12 * time.Duration(i))
+		require.Equal(t, tm.Format(time.RFC3339), scheduled[tm.Format("2006-01-02 15:04:05")])
+	}
+
+	runAppTestOutput(makeApp(), appTest{
+		args: []string{"", "backfill", fmt.Sprintf("--config=%s", cfg),
+			"--from=2022-01-02", "--to=2022-01-01", testConfig("backfill.yaml")},
+		errored:    true,
+		errMessage: []string{"from must be before to"},
+	}, t)
+
+	runAppTestOutput(makeApp(), appTest{
+		args: []string{"", "backfill", fmt.Sprintf("--config=%s", cfg),
+			"--from=2022-01-01", "--to=2022-01-02", "--parallel=2", testConfig("backfill.yaml")},
+		errored:    true,
+		errMessage: []string{"require overlapPolicy: allow-parallel"},
+	}, t)
+}
+
+func Test_backfillCommandParallel(t *testing.T) {
+	bin, err := os.Getwd()
+	require.NoError(t, err)
+	cfg := path.Join(t.TempDir(), "admin.yaml")
+	require.NoError(t, os.WriteFile(cfg,
+		[]byte(fmt.Sprintf("command: %s\n", path.Join(bin, "../bin/dagu"))), 0644))
+
+	runAppTestOutput(makeApp(), appTest{
+		args: []string{"", "backfill", fmt.Sprintf("--config=%s", cfg),
+			"--from=2022-01-01", "--to=2022-01-01", "--parallel=2", testConfig("backfill_parallel.yaml")},
+		errored: false,
+		output: []string{
+			"[2022-01-01 00:00:00] finished",
+			"[2022-01-01 12:00:00] finished",
+		},
+	}, t)
+
+	// both runs started before either of them finished
+	d, err := (&dag.Loader{}).Load(testConfig("backfill_parallel.yaml"), "")
+	require.NoError(t, err)
+	statuses := controller.NewDAGController(d).GetRecentStatuses(2)
+	require.Len(t, statuses, 2)
+	for _, s := range statuses {
+		for _, o := range statuses {
+			require.Less(t, s.Status.StartedAt, o.Status.FinishedAt)
+		}
+	}
+}
+
+func TestBackfillTimes(t *testing.T) {
+	d, err := (&dag.Loader{}).Load(testConfig("backfill.yaml"), "")
+	require.NoError(t, err)
+
+	from, to, err := parseBackfillRange("2022-01-01 12:00:00", "2022-01-02 12:00:00")
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2022, 1, 1, 12, 0, 0, 0, time.Local),
+		time.Date(2022, 1, 2, 0, 0, 0, 0, time.Local),
+		time.Date(2022, 1, 2, 12, 0, 0, 0, time.Local),
+	}, backfillTimes(d, from, to))
+
+	from, to, err = parseBackfillRange("2022-01-01", "2022-01-01")
+	require.NoError(t, err)
+	require.Len(t, backfillTimes(d, from, to), 2)
+
+	_, _, err = parseBackfillRange("2022/01/01", "2022-01-01")
+	require.Error(t, err)
+}
@@ -7,11 +7,13 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata"
 
 	"github.com/urfave/cli/v2"
 	"github.com/yohamta/dagu/internal/admin"
 	"github.com/yohamta/dagu/internal/constants"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/pool"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/utils"
 )
@@ -70,6 +72,15 @@ func loadDAG(c *cli.Context, dagPath, params string) (d *dag.DAG, err error) {
 	return d, err
 }
 
+// loadPools returns the pools of the admin config given to the agent.
+func loadPools(c *cli.Context) ([]*pool.Pool, error) {
+	cfg, err := loadGlobalConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Pools, nil
+}
+
 func listenSignals(abortFunc func(sig os.Signal)) {
 	sigs = make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -95,7 +106,7 @@ func makeApp() *cli.App {
 	return &cli.App{
 		Name:      "Dagu",
 		Usage:     "Self-contained, easy-to-use workflow engine for smaller use cases",
-		UsageText: "dagu [options] <start|status|stop|retry|dry|server|scheduler|version> [args]",
+		UsageText: "dagu [options] <start|status|stop|retry|dry|backfill|server|scheduler|version> [args]",
 		Commands: []*cli.Command{
 			newStartCommand(),
 			newStatusCommand(),
@@ -103,6 +114,7 @@ func makeApp() *cli.App {
 			newRestartCommand(),
 			newRetryCommand(),
 			newDryCommand(),
+			newBackfillCommand(),
 			newServerCommand(),
 			newSchedulerCommand(),
 			newVersionCommand(),
@@ -6,6 +6,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"github.com/yohamta/dagu"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/pool"
 )
 
 func newDryCommand() *cli.Command {
@@ -26,15 +27,20 @@ func newDryCommand() *cli.Command {
 			if err != nil {
 				return err
 			}
-			return dryRun(d)
+			pools, err := loadPools(c)
+			if err != nil {
+				return err
+			}
+			return dryRun(d, pools)
 		},
 	}
 }
 
-func dryRun(d *dag.DAG) error {
+func dryRun(d *dag.DAG, pools []*pool.Pool) error {
 	a := &dagu.Agent{AgentConfig: &dagu.AgentConfig{
-		DAG: d,
-		Dry: true,
+		DAG:   d,
+		Dry:   true,
+		Pools: pools,
 	}}
 	listenSignals(func(sig os.Signal) {
 		a.Signal(sig)
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/urfave/cli/v2"
+	"github.com/yohamta/dagu"
 	"github.com/yohamta/dagu/internal/controller"
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/scheduler"
@@ -78,5 +79,9 @@ func restart(d *dag.DAG, ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return start(d)
+	pools, err := loadPools(ctx)
+	if err != nil {
+		return err
+	}
+	return start(&dagu.AgentConfig{DAG: d, Pools: pools})
 }
@@ -5,9 +5,9 @@ import (
 	"path/filepath"
 
 	"github.com/yohamta/dagu"
-	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/database"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/utils"
 
 	"github.com/urfave/cli/v2"
 )
@@ -24,6 +24,15 @@ func newRetryCommand() *cli.Command {
 				Value:    "",
 				Required: true,
 			},
+			&cli.StringFlag{
+				Name:     "new-req",
+				Usage:    "request-id of the new run",
+				Value:    "",
+				Required: false,
+				Hidden:   true,
+			},
+			parentFlag,
+			parentRequestIdFlag,
 		),
 		Action: func(c *cli.Context) error {
 			f, _ := filepath.Abs(c.Args().Get(0))
@@ -34,17 +43,27 @@ func newRetryCommand() *cli.Command {
 				return err
 			}
 			d, err := loadDAG(c, c.Args().Get(0), status.Status.Params)
-			return retry(d, status)
+			if err != nil {
+				return err
+			}
+			pools, err := loadPools(c)
+			if err != nil {
+				return err
+			}
+			return retry(&dagu.AgentConfig{
+				DAG:             d,
+				RequestId:       c.String("new-req"),
+				Parent:          utils.StringWithFallback(c.String("parent"), status.Status.Parent),
+				ParentRequestId: utils.StringWithFallback(c.String("parent-req"), status.Status.ParentRequestId),
+				Pools:           pools,
+			}, status)
 		},
 	}
 }
 
-func retry(d *dag.DAG, status *models.StatusFile) error {
+func retry(cfg *dagu.AgentConfig, status *models.StatusFile) error {
 	a := &dagu.Agent{
-		AgentConfig: &dagu.AgentConfig{
-			DAG: d,
-			Dry: false,
-		},
+		AgentConfig: cfg,
 		RetryConfig: &dagu.RetryConfig{
 			Status: status.Status,
 		},
@@ -3,10 +3,10 @@ package main
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"github.com/yohamta/dagu"
-	"github.com/yohamta/dagu/internal/dag"
 )
 
 func newStartCommand() *cli.Command {
@@ -21,22 +21,75 @@ func newStartCommand() *cli.Command {
 				Value:    "",
 				Required: false,
 			},
+			&cli.StringFlag{
+				Name:     "req",
+				Usage:    "request-id",
+				Value:    "",
+				Required: false,
+			},
+			&cli.TimestampFlag{
+				Name:     "scheduled-time",
+				Usage:    "time the run is scheduled at",
+				Layout:   time.RFC3339,
+				Required: false,
+				Hidden:   true,
+			},
+			&cli.StringFlag{
+				Name:     "backfill",
+				Usage:    "backfill ID",
+				Value:    "",
+				Required: false,
+				Hidden:   true,
+			},
+			parentFlag,
+			parentRequestIdFlag,
 		),
 		Action: func(c *cli.Context) error {
 			d, err := loadDAG(c, c.Args().Get(0), strings.Trim(c.String("params"), "\""))
 			if err != nil {
 				return err
 			}
-			return start(d)
+			pools, err := loadPools(c)
+			if err != nil {
+				return err
+			}
+			cfg := &dagu.AgentConfig{
+				DAG:             d,
+				RequestId:       c.String("req"),
+				Parent:          c.String("parent"),
+				ParentRequestId: c.String("parent-req"),
+				BackfillId:      c.String("backfill"),
+				Pools:           pools,
+			}
+			if t := c.Timestamp("scheduled-time"); t != nil {
+				cfg.ScheduledTime = *t
+			}
+			return start(cfg)
 		},
 	}
 }
 
-func start(d *dag.DAG) error {
-	a := &dagu.Agent{AgentConfig: &dagu.AgentConfig{
-		DAG: d,
-		Dry: false,
-	}}
+// parentFlag and parentRequestIdFlag are set by the parent DAG
+// when the DAG runs as a sub-DAG.
+var (
+	parentFlag = &cli.StringFlag{
+		Name:     "parent",
+		Usage:    "parent DAG file",
+		Value:    "",
+		Required: false,
+		Hidden:   true,
+	}
+	parentRequestIdFlag = &cli.StringFlag{
+		Name:     "parent-req",
+		Usage:    "request-id of the parent DAG",
+		Value:    "",
+		Required: false,
+		Hidden:   true,
+	}
+)
+
+func start(cfg *dagu.AgentConfig) error {
+	a := &dagu.Agent{AgentConfig: cfg}
 
 	listenSignals(func(sig os.Signal) {
 		a.Signal(sig)
@@ -0,0 +1,315 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/admin/handlers"
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+func testAPI(t *testing.T, h http.Handler, method, url, body string, wantStatus int, ret interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, wantStatus, rec.Code, rec.Body.String())
+	if ret != nil {
+		require.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), ret))
+	}
+}
+
+func TestAPI(t *testing.T) {
+	settings.ChangeHomeDir(testHomeDir)
+	defer settings.ChangeHomeDir(testdataDir)
+
+	cfg := &Config{
+		DAGs:    t.TempDir(),
+		Command: path.Join(utils.MustGetwd(), "../../bin/dagu"),
+	}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+
+	apiErr := &handlers.APIError{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/none", "", http.StatusNotFound, apiErr)
+	require.Equal(t, "not_found", apiErr.Code)
+
+	testAPI(t, h, http.MethodPost, "/api/v1/dags", `{"Name":"../x"}`, http.StatusBadRequest, apiErr)
+	require.Equal(t, "bad_request", apiErr.Code)
+	testAPI(t, h, http.MethodPost, "/api/v1/dags", `{"Unknown":1}`, http.StatusBadRequest, apiErr)
+
+	testAPI(t, h, http.MethodPost, "/api/v1/dags", `{"Name":"api_test"}`, http.StatusCreated, &handlers.DAGName{})
+	testAPI(t, h, http.MethodPost, "/api/v1/dags", `{"Name":"api_test"}`, http.StatusConflict, apiErr)
+	require.Equal(t, "conflict", apiErr.Code)
+
+	spec := `steps:
+  - name: "1"
+    command: "echo hello"
+`
+	body, _ := json.Marshal(&handlers.DAGSpec{Spec: spec})
+	testAPI(t, h, http.MethodPut, "/api/v1/dags/api_test/spec", string(body), http.StatusOK, &handlers.DAGSpec{})
+	testAPI(t, h, http.MethodPut, "/api/v1/dags/api_test/spec", `{"Spec":"steps: x"}`, http.StatusBadRequest, apiErr)
+
+	gotSpec := &handlers.DAGSpec{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/spec", "", http.StatusOK, gotSpec)
+	require.Equal(t, spec, gotSpec.Spec)
+
+	revs := &handlers.RevisionList{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/revisions", "", http.StatusOK, revs)
+	require.Equal(t, 2, len(revs.Revisions))
+	first := revs.Revisions[1].Hash
+
+	diff := &handlers.RevisionDiff{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/revisions/"+first+"/diff", "", http.StatusOK, diff)
+	require.Equal(t, revs.Revisions[0].Hash, diff.To)
+	require.Contains(t, diff.Diff, "+    command: \"echo hello\"")
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/revisions/none", "", http.StatusNotFound, apiErr)
+
+	rev := &handlers.RevisionDetail{}
+	testAPI(t, h, http.MethodPost, "/api/v1/dags/api_test/revisions/"+first+"/rollback", "", http.StatusOK, rev)
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/revisions/"+rev.Hash, "", http.StatusOK, rev)
+	require.Equal(t, first, rev.Hash)
+	testAPI(t, h, http.MethodPut, "/api/v1/dags/api_test/spec", string(body), http.StatusOK, &handlers.DAGSpec{})
+
+	list := &handlers.DAGList{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags", "", http.StatusOK, list)
+	require.Equal(t, 1, len(list.DAGs))
+	require.Equal(t, "api_test", list.DAGs[0].Name)
+
+	detail := &handlers.DAGDetail{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test", "", http.StatusOK, detail)
+	require.Equal(t, 1, len(detail.DAG.Steps))
+
+	suspended := &handlers.SuspendState{}
+	testAPI(t, h, http.MethodPut, "/api/v1/dags/api_test/suspend", `{"Suspended":true}`, http.StatusOK, suspended)
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/suspend", "", http.StatusOK, suspended)
+	require.True(t, suspended.Suspended)
+	testAPI(t, h, http.MethodPut, "/api/v1/dags/api_test/suspend", `{"Suspended":false}`, http.StatusOK, suspended)
+
+	testAPI(t, h, http.MethodPost, "/api/v1/dags/api_test/stop", "", http.StatusConflict, apiErr)
+
+	accepted := &handlers.RunAccepted{}
+	testAPI(t, h, http.MethodPost, "/api/v1/dags/api_test/runs", `{}`, http.StatusAccepted, accepted)
+	require.NotEmpty(t, accepted.RequestId)
+
+	runUrl := "/api/v1/dags/api_test/runs/" + accepted.RequestId
+	require.Eventually(t, func() bool {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/dags/api_test", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		d := &handlers.DAGDetail{}
+		_ = json.Unmarshal(rec.Body.Bytes(), d)
+		return d.Status != nil && d.Status.RequestId == accepted.RequestId &&
+			d.Status.Status == scheduler.SchedulerStatus_Success
+	}, time.Second*5, time.Millisecond*100)
+
+	runs := &handlers.RunList{}
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/runs?limit=10", "", http.StatusOK, runs)
+	require.Equal(t, 1, len(runs.Runs))
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/runs?limit=x", "", http.StatusBadRequest, apiErr)
+	testAPI(t, h, http.MethodGet, "/api/v1/dags/api_test/runs/none", "", http.StatusNotFound, apiErr)
+
+	steps := &handlers.StepList{}
+	testAPI(t, h, http.MethodGet, runUrl+"/steps", "", http.StatusOK, steps)
+	require.Equal(t, 1, len(steps.Steps))
+
+	stepLog := &handlers.LogContent{}
+	testAPI(t, h, http.MethodGet, runUrl+"/steps/1/log", "", http.StatusOK, stepLog)
+	require.Equal(t, "hello\n", stepLog.Content)
+	testAPI(t, h, http.MethodGet, runUrl+"/log", "", http.StatusOK, &handlers.LogContent{})
+	testAPI(t, h, http.MethodGet, runUrl+"/steps/2", "", http.StatusNotFound, apiErr)
+
+	node := &models.Node{}
+	testAPI(t, h, http.MethodPatch, runUrl+"/steps/1", `{"Status":"failed"}`, http.StatusOK, node)
+	require.Equal(t, scheduler.NodeStatus_Error, node.Status)
+	testAPI(t, h, http.MethodGet, runUrl+"/steps/1", "", http.StatusOK, node)
+	require.Equal(t, scheduler.NodeStatus_Error, node.Status)
+	testAPI(t, h, http.MethodPatch, runUrl+"/steps/1", `{"Status":"x"}`, http.StatusBadRequest, apiErr)
+
+	testAPI(t, h, http.MethodPost, "/api/v1/dags/api_test/rename", `{"Name":"api_test2"}`, http.StatusOK, &handlers.DAGName{})
+	testAPI(t, h, http.MethodDelete, "/api/v1/dags/api_test2", "", http.StatusNoContent, nil)
+	testAPI(t, h, http.MethodDelete, "/api/v1/dags/api_test2", "", http.StatusNotFound, apiErr)
+}
+
+func TestAPIOpenAPIDocument(t *testing.T) {
+	cfg := &Config{DAGs: t.TempDir()}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+
+	doc := map[string]interface{}{}
+	testAPI(t, h, http.MethodGet, "/api/v1/openapi.json", "", http.StatusOK, &doc)
+	require.Equal(t, "3.0.3", doc["openapi"])
+
+	paths := doc["paths"].(map[string]interface{})
+	for _, r := range handlers.APIRoutes(&handlers.APIConfig{}) {
+		p, ok := paths[r.Path].(map[string]interface{})
+		require.True(t, ok, r.Path)
+		require.Contains(t, p, strings.ToLower(r.Method))
+	}
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	require.Contains(t, schemas, "ModelsStatus")
+	require.Contains(t, schemas, "APIError")
+}
+
+func TestAPIStream(t *testing.T) {
+	settings.ChangeHomeDir(testHomeDir)
+	defer settings.ChangeHomeDir(testdataDir)
+
+	cfg := &Config{
+		DAGs:    t.TempDir(),
+		Command: path.Join(utils.MustGetwd(), "../../bin/dagu"),
+	}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+
+	spec := `steps:
+  - name: "1"
+    command: "sh -c 'echo foo; sleep 1; echo bar'"
+`
+	body, _ := json.Marshal(&handlers.DAGSpec{Spec: spec})
+	testAPI(t, h, http.MethodPost, "/api/v1/dags", `{"Name":"stream_test"}`, http.StatusCreated, &handlers.DAGName{})
+	testAPI(t, h, http.MethodPut, "/api/v1/dags/stream_test/spec", string(body), http.StatusOK, &handlers.DAGSpec{})
+
+	accepted := &handlers.RunAccepted{}
+	testAPI(t, h, http.MethodPost, "/api/v1/dags/stream_test/runs", `{}`, http.StatusAccepted, accepted)
+	runUrl := "/api/v1/dags/stream_test/runs/" + accepted.RequestId
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, runUrl, nil))
+		return rec.Code == http.StatusOK
+	}, time.Second*5, time.Millisecond*100)
+
+	stream := func(url string, header http.Header) string {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		return rec.Body.String()
+	}
+
+	// the stream ends when the run has finished
+	got := stream(runUrl+"/steps/1/log/stream", nil)
+	require.Contains(t, got, "event: log\nid: 4\ndata: {\"Offset\":4,\"Content\":\"foo\\n\"}\n\n")
+	require.Contains(t, got, "event: log\nid: 8\ndata: {\"Offset\":8,\"Content\":\"bar\\n\"}\n\n")
+	require.True(t, strings.HasSuffix(got, "event: end\nid: 8\ndata: {\"Offset\":8,\"Content\":\"\"}\n\n"), got)
+
+	got = stream(runUrl+"/steps/1/log/stream?offset=4", nil)
+	require.NotContains(t, got, "foo")
+	require.Contains(t, got, "bar")
+	got = stream(runUrl+"/steps/1/log/stream", http.Header{"Last-Event-Id": {"8"}})
+	require.Equal(t, "event: end\nid: 8\ndata: {\"Offset\":8,\"Content\":\"\"}\n\n", got)
+	require.Contains(t, stream(runUrl+"/log/stream", nil), "event: end")
+
+	apiErr := &handlers.APIError{}
+	testAPI(t, h, http.MethodGet, runUrl+"/steps/1/log/stream?offset=x", "", http.StatusBadRequest, apiErr)
+	testAPI(t, h, http.MethodGet, runUrl+"/steps/2/log/stream", "", http.StatusNotFound, apiErr)
+
+	// the page of the DAG shows the run of the request ID
+	page := func(query string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/dags/stream_test?"+query, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		ret := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+		return ret
+	}
+	data := page("requestId=" + accepted.RequestId)
+	require.Equal(t, accepted.RequestId, data["DAG"].(map[string]interface{})["Status"].(map[string]interface{})["RequestId"])
+	require.Empty(t, data["Errors"])
+	require.NotEmpty(t, page("requestId=unknown")["Errors"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/dags/stream_test/status/stream", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, 1, strings.Count(rec.Body.String(), "event: status\n"))
+	require.Contains(t, rec.Body.String(), accepted.RequestId)
+}
+
+func TestAPISubdirectories(t *testing.T) {
+	settings.ChangeHomeDir(testHomeDir)
+	defer settings.ChangeHomeDir(testdataDir)
+
+	cfg := &Config{
+		DAGs: t.TempDir(),
+		Users: []*Principal{
+			{Name: "bob", Secret: "bob", Groups: map[string]Role{"team-a": RoleAdmin}},
+		},
+	}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+	bob := func(method, url, body string, want int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if strings.HasPrefix(body, "action=") {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		req.SetBasicAuth("bob", "bob")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, want, rec.Code, "%s %s: %s", method, url, rec.Body.String())
+		return rec
+	}
+
+	for _, name := range []string{"team-a/etl", "team-b/etl"} {
+		require.NoError(t, controller.CreateDAG(path.Join(cfg.DAGs, name+".yaml")))
+	}
+	bob(http.MethodPost, "/api/v1/dags", `{"Name":"../etl"}`, http.StatusForbidden)
+
+	// the DAGs in the groups without a role are not listed
+	list := &handlers.DAGList{}
+	require.NoError(t, json.Unmarshal(bob(http.MethodGet, "/api/v1/dags", "", http.StatusOK).Body.Bytes(), list))
+	require.Equal(t, 1, len(list.DAGs))
+	require.Equal(t, "team-a/etl", list.DAGs[0].Name)
+	require.Equal(t, "team-a", list.DAGs[0].Group)
+	req := httptest.NewRequest(http.MethodGet, "/search?q=step1", nil)
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth("bob", "bob")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "team-a/etl")
+	require.NotContains(t, rec.Body.String(), "team-b/etl")
+
+	detail := &handlers.DAGDetail{}
+	require.NoError(t, json.Unmarshal(bob(http.MethodGet, "/api/v1/dags/team-a%2Fetl", "", http.StatusOK).Body.Bytes(), detail))
+	require.Equal(t, "team-a/etl", detail.Name)
+	bob(http.MethodGet, "/api/v1/dags/team-b%2Fetl", "", http.StatusForbidden)
+	bob(http.MethodGet, "/api/v1/dags/team-a%2Fnone", "", http.StatusNotFound)
+	bob(http.MethodGet, "/dags/team-a%2Fetl/spec", "", http.StatusOK)
+	bob(http.MethodGet, "/dags/team-a%2F..%2F..%2Fetl", "", http.StatusForbidden)
+
+	bob(http.MethodPut, "/api/v1/dags/team-a%2Fetl/suspend", `{"Suspended":true}`, http.StatusOK)
+	require.NoError(t, json.Unmarshal(bob(http.MethodGet, "/api/v1/dags", "", http.StatusOK).Body.Bytes(), list))
+	require.True(t, list.DAGs[0].Suspended)
+
+	// the DAGs cannot be created or renamed into the groups without the role
+	bob(http.MethodPost, "/api/v1/dags", `{"Name":"team-b/new"}`, http.StatusForbidden)
+	bob(http.MethodPost, "/api/v1/dags", `{"Name":"new"}`, http.StatusForbidden)
+	bob(http.MethodPost, "/", url.Values{"action": {"new"}, "value": {"team-b/new"}}.Encode(), http.StatusForbidden)
+	bob(http.MethodPost, "/api/v1/dags", `{"Name":"team-a/new"}`, http.StatusCreated)
+	bob(http.MethodPost, "/api/v1/dags/team-a%2Fetl/rename", `{"Name":"team-b/moved"}`, http.StatusForbidden)
+	bob(http.MethodPost, "/dags/team-a%2Fetl", url.Values{"action": {"rename"}, "value": {"team-b/moved"}}.Encode(), http.StatusForbidden)
+	require.False(t, utils.FileExists(path.Join(cfg.DAGs, "team-b/moved.yaml")))
+
+	bob(http.MethodPost, "/api/v1/dags/team-a%2Fetl/rename", `{"Name":"team-a/sub/etl"}`, http.StatusOK)
+	bob(http.MethodGet, "/api/v1/dags/team-a%2Fsub%2Fetl", "", http.StatusOK)
+	bob(http.MethodDelete, "/api/v1/dags/team-a%2Fsub%2Fetl", "", http.StatusNoContent)
+	bob(http.MethodDelete, "/api/v1/dags/team-b%2Fetl", "", http.StatusForbidden)
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/admin/handlers"
+	"github.com/yohamta/dagu/internal/settings"
+)
+
+func TestAuditLog(t *testing.T) {
+	settings.ChangeHomeDir(t.TempDir())
+	defer settings.ChangeHomeDir(testdataDir)
+
+	cfg := &Config{
+		DAGs: t.TempDir(),
+		Users: []*Principal{
+			{Name: "alice", Secret: "alice", Role: RoleAdmin},
+		},
+		Tokens: []*Principal{
+			{Name: "ci", Secret: "token", Role: RoleOperator},
+		},
+	}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+
+	do := func(method, url, contentType, body string, admin bool, want int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if admin {
+			req.SetBasicAuth("alice", "alice")
+		} else {
+			req.Header.Set("Authorization", "Bearer token")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, want, rec.Code, rec.Body.String())
+		return rec
+	}
+
+	do(http.MethodPost, "/api/v1/dags", "", `{"Name":"audit_test"}`, true, http.StatusCreated)
+	do(http.MethodPut, "/api/v1/dags/audit_test/spec", "",
+		`{"Spec":"steps:\n  - name: \"1\"\n    command: \"true\"\n"}`, true, http.StatusOK)
+	do(http.MethodPost, "/dags/audit_test", "application/x-www-form-urlencoded",
+		url.Values{"action": {"suspend"}, "value": {"true"}}.Encode(), false, http.StatusSeeOther)
+
+	list := &handlers.AuditList{}
+	rec := do(http.MethodGet, "/api/v1/audit", "", "", true, http.StatusOK)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), list))
+	require.Equal(t, 3, len(list.Entries))
+
+	suspended := list.Entries[0]
+	require.Equal(t, "ci", suspended.User)
+	require.Equal(t, "audit_test", suspended.DAG)
+	require.Equal(t, "suspend", suspended.Action)
+	require.Equal(t, "true", suspended.Params["suspend"])
+	require.NotEmpty(t, suspended.RemoteAddr)
+
+	saved := list.Entries[1]
+	require.Equal(t, "alice", saved.User)
+	require.Equal(t, "save", saved.Action)
+	require.Contains(t, saved.Diff, "+  - name: \"1\"\n")
+
+	require.Equal(t, "new", list.Entries[2].Action)
+
+	rec = do(http.MethodGet, "/api/v1/audit?user=alice&action=save", "", "", true, http.StatusOK)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), list))
+	require.Equal(t, 1, len(list.Entries))
+
+	do(http.MethodGet, "/api/v1/audit?since=yesterday", "", "", true, http.StatusBadRequest)
+	do(http.MethodGet, "/api/v1/audit", "", "", false, http.StatusForbidden)
+
+	req := httptest.NewRequest(http.MethodGet, "/audit?limit=1", nil)
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth("alice", "alice")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), list))
+	require.Equal(t, 1, len(list.Entries))
+	require.Equal(t, "suspend", list.Entries[0].Action)
+}
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yohamta/dagu/internal/admin/handlers"
+	"github.com/yohamta/dagu/internal/dag"
+)
+
+// Role is the role of a user or an API token.
+type Role int
+
+const (
+	RoleNone Role = iota
+	// RoleViewer can view DAGs, runs and logs.
+	RoleViewer
+	// RoleOperator can start, stop, retry and suspend DAGs in addition.
+	RoleOperator
+	// RoleAdmin can create, edit, rename and delete DAGs in addition.
+	RoleAdmin
+)
+
+var roleNames = map[string]Role{
+	"":         RoleNone,
+	"viewer":   RoleViewer,
+	"operator": RoleOperator,
+	"admin":    RoleAdmin,
+}
+
+func parseRole(s string) (Role, error) {
+	r, ok := roleNames[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return RoleNone, fmt.Errorf("invalid role: %s", s)
+	}
+	return r, nil
+}
+
+func (r Role) allows(a handlers.Access) bool {
+	switch a {
+	case handlers.AccessRead:
+		return r >= RoleViewer
+	case handlers.AccessOperate:
+		return r >= RoleOperator
+	}
+	return r >= RoleAdmin
+}
+
+// Principal is a user or an API token that can access the admin server.
+type Principal struct {
+	Name string
+	// Secret is the password of the user or the token.
+	Secret string
+	// Role is the role for the DAGs not in Groups.
+	Role Role
+	// Groups is the roles for the DAGs in each group.
+	Groups map[string]Role
+}
+
+// roleFor returns the role for the DAGs in the group. The role for a group
+// applies to the groups of its subdirectories unless they are given.
+func (p *Principal) roleFor(group string) Role {
+	for g := group; g != "" && g != "." && g != "/"; g = path.Dir(g) {
+		if r, ok := p.Groups[g]; ok {
+			return r
+		}
+	}
+	return p.Role
+}
+
+func (p *Principal) maxRole() Role {
+	ret := p.Role
+	for _, r := range p.Groups {
+		if r > ret {
+			ret = r
+		}
+	}
+	return ret
+}
+
+// authenticate returns the user of the basic auth credentials or the API
+// token of the bearer token. It returns nil if neither matches.
+func authenticate(r *http.Request, users, tokens []*Principal) *Principal {
+	if token, ok := bearerToken(r); ok {
+		for _, t := range tokens {
+			if secureCompare(t.Secret, token) {
+				return t
+			}
+		}
+		return nil
+	}
+	// Reference: https://www.alexedwards.net/blog/basic-authentication-in-go
+	if username, password, ok := r.BasicAuth(); ok {
+		for _, u := range users {
+			nameMatch := secureCompare(u.Name, username)
+			passwordMatch := secureCompare(u.Secret, password)
+			if nameMatch && passwordMatch {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+	return h[len(prefix):], true
+}
+
+func secureCompare(expected, actual string) bool {
+	expectedHash := sha256.Sum256([]byte(expected))
+	actualHash := sha256.Sum256([]byte(actual))
+	return subtle.ConstantTimeCompare(expectedHash[:], actualHash[:]) == 1
+}
+
+// dagGroup returns the group of the DAG. The group of a DAG in a
+// subdirectory is the subdirectory even if the DAG does not exist. It returns
+// an empty string if the DAG at the top of the DAGs directory does not exist
+// or cannot be loaded.
+func dagGroup(dagsDir, name string) string {
+	name = strings.TrimSuffix(name, ".yaml")
+	if name == "" || strings.Contains(name, `\`) || strings.Contains("/"+name+"/", "/../") {
+		return ""
+	}
+	cl := &dag.Loader{}
+	d, err := cl.LoadHeadOnly(filepath.Join(dagsDir, name+".yaml"))
+	if err != nil {
+		if dir := path.Dir(name); dir != "." {
+			return dir
+		}
+		return ""
+	}
+	d.SetDAGsDir(dagsDir)
+	return d.Group
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+	if strings.HasPrefix(r.URL.Path, handlers.APIPrefix) {
+		handlers.WriteAPIError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, handlers.APIPrefix) {
+		handlers.WriteAPIError(w, http.StatusForbidden, fmt.Errorf("forbidden"))
+		return
+	}
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
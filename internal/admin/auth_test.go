@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/admin/handlers"
+	"github.com/yohamta/dagu/internal/settings"
+)
+
+func TestAuthorization(t *testing.T) {
+	settings.ChangeHomeDir(testHomeDir)
+	defer settings.ChangeHomeDir(testdataDir)
+
+	dir := t.TempDir()
+	for name, yaml := range map[string]string{
+		"etl.yaml":  "group: etl\nsteps:\n  - name: \"1\"\n    command: \"true\"\n",
+		"misc.yaml": "steps:\n  - name: \"1\"\n    command: \"true\"\n",
+	} {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte(yaml), 0644))
+	}
+	cfg := &Config{
+		DAGs: dir,
+		Users: []*Principal{
+			{Name: "alice", Secret: "alice", Role: RoleAdmin},
+			{Name: "bob", Secret: "bob", Groups: map[string]Role{"etl": RoleOperator}},
+		},
+		Tokens: []*Principal{
+			{Name: "ci", Secret: "token", Role: RoleViewer},
+		},
+	}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+
+	type auth func(r *http.Request)
+	basic := func(user string) auth {
+		return func(r *http.Request) { r.SetBasicAuth(user, user) }
+	}
+	bearer := func(token string) auth {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+	form := func(action string) string {
+		return url.Values{"action": {action}}.Encode()
+	}
+
+	for _, test := range []struct {
+		Auth   auth
+		Method string
+		Url    string
+		Body   string
+		Want   int
+	}{
+		{nil, http.MethodGet, "/api/v1/dags", "", http.StatusUnauthorized},
+		{basic("mallory"), http.MethodGet, "/api/v1/dags", "", http.StatusUnauthorized},
+		{bearer("invalid"), http.MethodGet, "/api/v1/dags", "", http.StatusUnauthorized},
+
+		{bearer("token"), http.MethodGet, "/api/v1/dags", "", http.StatusOK},
+		{bearer("token"), http.MethodGet, "/api/v1/dags/etl", "", http.StatusOK},
+		{bearer("token"), http.MethodPost, "/api/v1/dags/etl/stop", "", http.StatusForbidden},
+		{bearer("token"), http.MethodPost, "/dags/etl", form("stop"), http.StatusForbidden},
+
+		{basic("bob"), http.MethodGet, "/api/v1/dags", "", http.StatusOK},
+		{basic("bob"), http.MethodGet, "/api/v1/dags/misc", "", http.StatusForbidden},
+		{basic("bob"), http.MethodPost, "/api/v1/dags", `{"Name":"new"}`, http.StatusForbidden},
+		{basic("bob"), http.MethodPost, "/api/v1/dags", `{"Name":"etl/new"}`, http.StatusForbidden},
+		{basic("bob"), http.MethodPost, "/api/v1/dags/etl/stop", "", http.StatusConflict},
+		{basic("bob"), http.MethodPost, "/dags/etl", form("stop"), http.StatusBadRequest},
+		{basic("bob"), http.MethodPost, "/dags/etl", form("save"), http.StatusForbidden},
+		{basic("bob"), http.MethodDelete, "/dags/etl", "", http.StatusForbidden},
+		{basic("bob"), http.MethodPost, "/dags/misc", form("stop"), http.StatusForbidden},
+
+		{basic("alice"), http.MethodPost, "/api/v1/dags/misc/stop", "", http.StatusConflict},
+		{basic("alice"), http.MethodDelete, "/api/v1/dags/misc", "", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(test.Method, test.Url, strings.NewReader(test.Body))
+		if strings.HasPrefix(test.Body, "action=") {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if test.Auth != nil {
+			test.Auth(req)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, test.Want, rec.Code, "%s %s", test.Method, test.Url)
+	}
+}
+
+func TestAuthorizationBasicAuth(t *testing.T) {
+	settings.ChangeHomeDir(testHomeDir)
+	defer settings.ChangeHomeDir(testdataDir)
+
+	cfg := &Config{
+		DAGs:              t.TempDir(),
+		IsBasicAuth:       true,
+		BasicAuthUsername: "user",
+		BasicAuthPassword: "password",
+	}
+	h := newAdminHandler(cfg, defaultRoutes(cfg))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dags", strings.NewReader(`{"Name":"new"}`))
+	req.SetBasicAuth("user", "password")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestRoleAllows(t *testing.T) {
+	for _, test := range []struct {
+		Role   Role
+		Access handlers.Access
+		Want   bool
+	}{
+		{RoleNone, handlers.AccessRead, false},
+		{RoleViewer, handlers.AccessRead, true},
+		{RoleViewer, handlers.AccessOperate, false},
+		{RoleOperator, handlers.AccessOperate, true},
+		{RoleOperator, handlers.AccessEdit, false},
+		{RoleAdmin, handlers.AccessEdit, true},
+	} {
+		require.Equal(t, test.Want, test.Role.allows(test.Access))
+	}
+}
@@ -1,29 +0,0 @@
-package admin
-
-import (
-	"crypto/sha256"
-	"crypto/subtle"
-	"net/http"
-)
-
-func basicAuth(next http.Handler, expectedUsername, expectedPassword string) http.Handler {
-	return http.HandlerFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			// Reference: https://www.alexedwards.net/blog/basic-authentication-in-go
-			username, password, ok := r.BasicAuth()
-			if ok {
-				usernameHash := sha256.Sum256([]byte(username))
-				passwordHash := sha256.Sum256([]byte(password))
-				expectedUsernameHash := sha256.Sum256([]byte(expectedUsername))
-				expectedPasswordHash := sha256.Sum256([]byte(expectedPassword))
-				usernameMatch := (subtle.ConstantTimeCompare(usernameHash[:], expectedUsernameHash[:]) == 1)
-				passwordMatch := (subtle.ConstantTimeCompare(passwordHash[:], expectedPasswordHash[:]) == 1)
-				if usernameMatch && passwordMatch {
-					next.ServeHTTP(w, r)
-					return
-				}
-			}
-			w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		})
-}
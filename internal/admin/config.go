@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/yohamta/dagu/internal/pool"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/utils"
 )
@@ -26,6 +27,10 @@ type Config struct {
 	BaseConfig         string
 	NavbarColor        string
 	NavbarTitle        string
+	Users              []*Principal
+	Tokens             []*Principal
+	// Pools are the pools of slots shared by the steps of all DAGs.
+	Pools []*pool.Pool
 }
 
 func newConfig() *Config {
@@ -134,6 +139,21 @@ func buildFromDefinition(def *configDefinition) (cfg *Config, err error) {
 			}
 			return nil
 		},
+		func(cfg *Config, def *configDefinition) (err error) {
+			cfg.Users, err = buildPrincipals(def.Users, func(d *principalDefinition) string {
+				return d.Password
+			})
+			if err != nil {
+				return fmt.Errorf("invalid users: %w", err)
+			}
+			cfg.Tokens, err = buildPrincipals(def.Tokens, func(d *principalDefinition) string {
+				return d.Token
+			})
+			if err != nil {
+				return fmt.Errorf("invalid tokens: %w", err)
+			}
+			return nil
+		},
 		func(cfg *Config, def *configDefinition) (err error) {
 			cfg.LogEncodingCharset, err = utils.ParseVariable(def.LogEncodingCharset)
 			return err
@@ -148,6 +168,13 @@ func buildFromDefinition(def *configDefinition) (cfg *Config, err error) {
 			}
 			return nil
 		},
+		func(cfg *Config, def *configDefinition) (err error) {
+			cfg.Pools, err = buildPools(def.Pools)
+			if err != nil {
+				return fmt.Errorf("invalid pools: %w", err)
+			}
+			return nil
+		},
 		func(cfg *Config, def *configDefinition) error {
 			cfg.NavbarColor = def.NavbarColor
 			cfg.NavbarTitle = def.NavbarTitle
@@ -165,6 +192,52 @@ func buildFromDefinition(def *configDefinition) (cfg *Config, err error) {
 	return cfg, nil
 }
 
+func buildPrincipals(defs []*principalDefinition, secret func(d *principalDefinition) string) ([]*Principal, error) {
+	var ret []*Principal
+	for _, d := range defs {
+		if d.Name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		p := &Principal{Name: d.Name, Groups: map[string]Role{}}
+		var err error
+		if p.Secret, err = utils.ParseVariable(secret(d)); err != nil {
+			return nil, err
+		}
+		if p.Secret == "" {
+			return nil, fmt.Errorf("%s: password or token is required", d.Name)
+		}
+		if p.Role, err = parseRole(d.Role); err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Name, err)
+		}
+		for g, r := range d.Groups {
+			if p.Groups[g], err = parseRole(r); err != nil {
+				return nil, fmt.Errorf("%s: %w", d.Name, err)
+			}
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+func buildPools(defs []*poolDefinition) ([]*pool.Pool, error) {
+	var ret []*pool.Pool
+	names := map[string]bool{}
+	for _, d := range defs {
+		if d.Name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("%s: duplicate pool", d.Name)
+		}
+		if d.Slots < 1 {
+			return nil, fmt.Errorf("%s: slots must be greater than 0", d.Name)
+		}
+		names[d.Name] = true
+		ret = append(ret, pool.New(d.Name, d.Slots))
+	}
+	return ret, nil
+}
+
 func buildConfigEnv(vars map[string]string) []string {
 	ret := []string{}
 	for k, v := range vars {
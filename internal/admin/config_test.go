@@ -110,3 +110,98 @@ func TestLoadInvalidConfigError(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigUsers(t *testing.T) {
+	os.Setenv("TEST_API_TOKEN", "token")
+	l := &Loader{}
+	d, err := l.unmarshalData([]byte(`
+users:
+  - name: alice
+    password: secret
+    role: admin
+  - name: bob
+    password: secret2
+    groups:
+      etl: Operator
+tokens:
+  - name: ci
+    token: ${TEST_API_TOKEN}
+    role: viewer
+`))
+	require.NoError(t, err)
+
+	def, err := l.decode(d)
+	require.NoError(t, err)
+
+	c, err := buildFromDefinition(def)
+	require.NoError(t, err)
+
+	require.Equal(t, []*Principal{
+		{Name: "alice", Secret: "secret", Role: RoleAdmin, Groups: map[string]Role{}},
+		{Name: "bob", Secret: "secret2", Role: RoleNone, Groups: map[string]Role{"etl": RoleOperator}},
+	}, c.Users)
+	require.Equal(t, []*Principal{
+		{Name: "ci", Secret: "token", Role: RoleViewer, Groups: map[string]Role{}},
+	}, c.Tokens)
+
+	for i, c := range []string{
+		`users: [{name: alice, password: secret, role: owner}]`,
+		`users: [{name: alice, password: secret, groups: {etl: owner}}]`,
+		`users: [{name: alice}]`,
+		`users: [{password: secret}]`,
+		`tokens: [{name: ci, password: secret}]`,
+	} {
+		t.Run(fmt.Sprintf("test-invalid-principal-%d", i), func(t *testing.T) {
+			d, err := l.unmarshalData([]byte(c))
+			require.NoError(t, err)
+
+			def, err := l.decode(d)
+			require.NoError(t, err)
+
+			_, err = buildFromDefinition(def)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigPools(t *testing.T) {
+	l := &Loader{}
+	d, err := l.unmarshalData([]byte(`
+pools:
+  - name: warehouse
+    slots: 4
+  - name: api
+    slots: 1
+`))
+	require.NoError(t, err)
+
+	def, err := l.decode(d)
+	require.NoError(t, err)
+
+	c, err := buildFromDefinition(def)
+	require.NoError(t, err)
+
+	require.Len(t, c.Pools, 2)
+	require.Equal(t, "warehouse", c.Pools[0].Name)
+	require.Equal(t, 4, c.Pools[0].Slots)
+	require.Equal(t, "api", c.Pools[1].Name)
+	require.Equal(t, 1, c.Pools[1].Slots)
+
+	for i, c := range []string{
+		`pools: [{slots: 1}]`,
+		`pools: [{name: warehouse}]`,
+		`pools: [{name: warehouse, slots: -1}]`,
+		`pools: [{name: warehouse, slots: 1}, {name: warehouse, slots: 2}]`,
+	} {
+		t.Run(fmt.Sprintf("test-invalid-pool-%d", i), func(t *testing.T) {
+			d, err := l.unmarshalData([]byte(c))
+			require.NoError(t, err)
+
+			def, err := l.decode(d)
+			require.NoError(t, err)
+
+			_, err = buildFromDefinition(def)
+			require.Error(t, err)
+		})
+	}
+}
@@ -15,4 +15,20 @@ type configDefinition struct {
 	LogEncodingCharset string
 	NavbarColor        string
 	NavbarTitle        string
+	Users              []*principalDefinition
+	Tokens             []*principalDefinition
+	Pools              []*poolDefinition
+}
+
+type principalDefinition struct {
+	Name     string
+	Password string
+	Token    string
+	Role     string
+	Groups   map[string]string
+}
+
+type poolDefinition struct {
+	Name  string
+	Slots int
 }
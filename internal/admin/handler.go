@@ -2,18 +2,33 @@ package admin
 
 import (
 	"net/http"
+	"net/url"
 	"regexp"
+
+	"github.com/yohamta/dagu/internal/admin/handlers"
+	"github.com/yohamta/dagu/internal/audit"
 )
 
 type adminHandler struct {
 	config *Config
-	routes map[string]map[*regexp.Regexp]http.HandlerFunc
+	routes map[string]map[*regexp.Regexp]*route
+	users  []*Principal
+	tokens []*Principal
 }
 
 func newAdminHandler(cfg *Config, routes []*route) *adminHandler {
 	hdl := &adminHandler{
 		config: cfg,
-		routes: map[string]map[*regexp.Regexp]http.HandlerFunc{},
+		routes: map[string]map[*regexp.Regexp]*route{},
+		users:  cfg.Users,
+		tokens: cfg.Tokens,
+	}
+	if cfg.IsBasicAuth {
+		hdl.users = append([]*Principal{{
+			Name:   cfg.BasicAuthUsername,
+			Secret: cfg.BasicAuthPassword,
+			Role:   RoleAdmin,
+		}}, hdl.users...)
 	}
 	hdl.configure(routes)
 	return hdl
@@ -21,15 +36,15 @@ func newAdminHandler(cfg *Config, routes []*route) *adminHandler {
 
 func (hdl *adminHandler) configure(routes []*route) {
 	for _, route := range routes {
-		hdl.addRoute(route.method, route.pattern, route.handler)
+		hdl.addRoute(route)
 	}
 }
 
-func (hdl *adminHandler) addRoute(method, pattern string, handler http.HandlerFunc) {
-	if _, ok := hdl.routes[method]; !ok {
-		hdl.routes[method] = map[*regexp.Regexp]http.HandlerFunc{}
+func (hdl *adminHandler) addRoute(r *route) {
+	if _, ok := hdl.routes[r.method]; !ok {
+		hdl.routes[r.method] = map[*regexp.Regexp]*route{}
 	}
-	hdl.routes[method][regexp.MustCompile(pattern)] = handler
+	hdl.routes[r.method][regexp.MustCompile(r.pattern)] = r
 }
 
 func (hdl *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -37,13 +52,55 @@ func (hdl *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
+	var p *Principal
+	if hdl.authEnabled() {
+		if p = authenticate(r, hdl.users, hdl.tokens); p == nil {
+			unauthorized(w, r)
+			return
+		}
+		ctx := audit.WithUser(r.Context(), p.Name)
+		ctx = handlers.WithAuthorizer(ctx, func(group string, access handlers.Access) bool {
+			return p.roleFor(group).allows(access)
+		})
+		r = r.WithContext(ctx)
+	}
 	if patterns, ok := hdl.routes[r.Method]; ok {
-		for re, handler := range patterns {
-			if re.MatchString(r.URL.Path) {
-				handler(w, r)
+		for re, route := range patterns {
+			if m := re.FindStringSubmatch(r.URL.EscapedPath()); m != nil {
+				if p != nil && !hdl.authorize(p, r, route, m) {
+					forbidden(w, r)
+					return
+				}
+				route.handler(w, r)
 				return
 			}
 		}
 	}
 	encodeError(w, errNotFound)
 }
+
+func (hdl *adminHandler) authEnabled() bool {
+	return len(hdl.users) > 0 || len(hdl.tokens) > 0
+}
+
+// authorize checks the role of the principal for the group of the DAG the
+// request is targeting, which is the first submatch of the route pattern
+// matched against the escaped path.
+// Pages not specific to a DAG can be viewed with a role in any group, as
+// the handlers check the role for the group of each DAG they list. A DAG
+// is created with the role for the group of the new DAG given in the
+// request. Other requests need the role of the principal for all groups.
+func (hdl *adminHandler) authorize(p *Principal, r *http.Request, route *route, m []string) bool {
+	access := route.access(r)
+	role := p.Role
+	switch {
+	case len(m) > 1:
+		name, _ := url.PathUnescape(m[1])
+		role = p.roleFor(dagGroup(hdl.config.DAGs, name))
+	case access == handlers.AccessRead:
+		role = p.maxRole()
+	case access == handlers.AccessEdit:
+		role = p.roleFor(dagGroup(hdl.config.DAGs, handlers.NewDAGName(r)))
+	}
+	return role.allows(access)
+}
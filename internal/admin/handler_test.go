@@ -11,10 +11,10 @@ import (
 
 func TestServeHTTP(t *testing.T) {
 	h := &adminHandler{
-		routes: map[string]map[*regexp.Regexp]http.HandlerFunc{},
+		routes: map[string]map[*regexp.Regexp]*route{},
 	}
 	fn := func(rw http.ResponseWriter, req *http.Request) {}
-	h.addRoute(http.MethodGet, "/", fn)
+	h.addRoute(&route{http.MethodGet, "/", fn, readAccess})
 
 	rw := &mockResponseWriter{}
 
@@ -0,0 +1,901 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/yohamta/dagu/internal/audit"
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/database"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/queue"
+	"github.com/yohamta/dagu/internal/revision"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/storage"
+	"github.com/yohamta/dagu/internal/suspend"
+	"github.com/yohamta/dagu/internal/utils"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// APIPrefix is the path prefix of the REST API.
+const APIPrefix = "/api/v1"
+
+// APIRoute is an endpoint of the REST API. Path parameters are written
+// as "{name}" in the path in the same way as the OpenAPI document.
+type APIRoute struct {
+	Method  string
+	Path    string
+	Summary string
+	// Request is the type of the JSON request body if any.
+	Request interface{}
+	// Response is the type of the JSON response body if any.
+	Response interface{}
+	// Status is the status code of the successful response.
+	Status int
+	// Access is the access level required to call the endpoint.
+	Access  Access
+	handler apiHandlerFunc
+}
+
+// Access is the access level required for a request.
+type Access int
+
+const (
+	// AccessRead is required to view DAGs, runs and logs.
+	AccessRead Access = iota
+	// AccessOperate is required to start, stop, retry or suspend DAGs.
+	AccessOperate
+	// AccessEdit is required to create, edit, rename or delete DAGs and
+	// to change the status of steps.
+	AccessEdit
+	// AccessAdmin is required to administer the server such as viewing
+	// the audit log.
+	AccessAdmin
+)
+
+type apiHandlerFunc func(w http.ResponseWriter, r *http.Request, p map[string]string)
+
+var apiPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Pattern returns the regular expression matching the path of the route.
+func (route *APIRoute) Pattern() string {
+	return fmt.Sprintf("^%s/?$", apiPathParam.ReplaceAllString(route.Path, `([^/]+)`))
+}
+
+// Handler returns the handler of the route with the path parameters parsed.
+func (route *APIRoute) Handler() http.HandlerFunc {
+	re := regexp.MustCompile(route.Pattern())
+	names := apiPathParam.FindAllStringSubmatch(route.Path, -1)
+	return func(w http.ResponseWriter, r *http.Request) {
+		m := re.FindStringSubmatch(r.URL.EscapedPath())
+		p := map[string]string{}
+		for i, n := range names {
+			if i+1 < len(m) {
+				v, err := url.PathUnescape(m[i+1])
+				if err != nil {
+					WriteAPIError(w, http.StatusBadRequest, err)
+					return
+				}
+				p[n[1]] = v
+			}
+		}
+		route.handler(w, r, p)
+	}
+}
+
+// APIError is the error response of the REST API.
+type APIError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+const (
+	apiErrorBadRequest   = "bad_request"
+	apiErrorUnauthorized = "unauthorized"
+	apiErrorForbidden    = "forbidden"
+	apiErrorNotFound     = "not_found"
+	apiErrorConflict     = "conflict"
+	apiErrorInternal     = "internal_error"
+)
+
+var apiErrorCodes = map[int]string{
+	http.StatusBadRequest:          apiErrorBadRequest,
+	http.StatusUnauthorized:        apiErrorUnauthorized,
+	http.StatusForbidden:           apiErrorForbidden,
+	http.StatusNotFound:            apiErrorNotFound,
+	http.StatusConflict:            apiErrorConflict,
+	http.StatusInternalServerError: apiErrorInternal,
+}
+
+// DAGSummary is a DAG in the list of DAGs.
+type DAGSummary struct {
+	Name        string         `json:"Name"`
+	File        string         `json:"File"`
+	Group       string         `json:"Group"`
+	Description string         `json:"Description"`
+	Tags        []string       `json:"Tags"`
+	Schedule    []string       `json:"Schedule"`
+	Timezone    string         `json:"Timezone"`
+	Suspended   bool           `json:"Suspended"`
+	Status      *models.Status `json:"Status"`
+	// Queue is the runs waiting for the running one in order.
+	Queue []*queue.Item `json:"Queue"`
+	// Running is the request IDs of the running runs, the latest first.
+	Running []string `json:"Running"`
+	Error   string   `json:"Error"`
+}
+
+// DAGList is the list of DAGs in the DAGs directory.
+type DAGList struct {
+	DAGs   []*DAGSummary `json:"DAGs"`
+	Errors []string      `json:"Errors"`
+}
+
+// DAGDetail is a DAG with its definition.
+type DAGDetail struct {
+	*DAGSummary
+	DAG *dag.DAG `json:"DAG"`
+	// Revision is the hash of the current definition.
+	Revision string `json:"Revision"`
+}
+
+// DAGName is the name of a DAG to create or rename to.
+type DAGName struct {
+	Name string `json:"Name"`
+}
+
+// DAGSpec is the YAML definition of a DAG.
+type DAGSpec struct {
+	Spec string `json:"Spec"`
+	// Message describes the change when updating the definition.
+	Message string `json:"Message,omitempty"`
+}
+
+// RevisionList is the list of the revisions of a DAG, newest first.
+type RevisionList struct {
+	Revisions []*revision.Revision `json:"Revisions"`
+}
+
+// RevisionDetail is a revision with its definition.
+type RevisionDetail struct {
+	*revision.Revision
+	Spec string `json:"Spec"`
+}
+
+// RevisionDiff is the unified diff between two definitions.
+type RevisionDiff struct {
+	From string `json:"From"`
+	To   string `json:"To"`
+	Diff string `json:"Diff"`
+}
+
+// SuspendState is the suspend state of a DAG.
+type SuspendState struct {
+	Suspended bool `json:"Suspended"`
+}
+
+// RunList is the list of the recent runs of a DAG.
+type RunList struct {
+	Runs []*models.Status `json:"Runs"`
+}
+
+// StartRunRequest is the request to start a new run of a DAG.
+type StartRunRequest struct {
+	Params string `json:"Params"`
+}
+
+// RunAccepted is the response to a request to start a run.
+type RunAccepted struct {
+	RequestId string `json:"RequestId"`
+}
+
+// StepList is the list of steps of a run including the handlers.
+type StepList struct {
+	Steps []*models.Node `json:"Steps"`
+}
+
+// StepStatusRequest is the request to change the status of a step.
+// Status is either "success" or "failed".
+type StepStatusRequest struct {
+	Status string `json:"Status"`
+}
+
+// LogContent is the content of a log file.
+type LogContent struct {
+	File    string `json:"File"`
+	Content string `json:"Content"`
+}
+
+// APIConfig is the configuration of the REST API.
+type APIConfig struct {
+	DAGsDir            string
+	Bin                string
+	WkDir              string
+	LogEncodingCharset string
+	AuditLog           *audit.Logger
+}
+
+// APIRoutes returns the endpoints of the REST API.
+func APIRoutes(cfg *APIConfig) []*APIRoute {
+	a := &api{cfg: cfg}
+	routes := []*APIRoute{
+		{http.MethodGet, "/dags", "List DAGs", nil, DAGList{}, http.StatusOK, AccessRead, a.listDAGs},
+		{http.MethodPost, "/dags", "Create a DAG", DAGName{}, DAGName{}, http.StatusCreated, AccessEdit, a.createDAG},
+		{http.MethodGet, "/dags/{name}", "Get a DAG", nil, DAGDetail{}, http.StatusOK, AccessRead, a.getDAG},
+		{http.MethodDelete, "/dags/{name}", "Delete a DAG", nil, nil, http.StatusNoContent, AccessEdit, a.deleteDAG},
+		{http.MethodPost, "/dags/{name}/rename", "Rename a DAG", DAGName{}, DAGName{}, http.StatusOK, AccessEdit, a.renameDAG},
+		{http.MethodGet, "/dags/{name}/spec", "Get the definition of a DAG", nil, DAGSpec{}, http.StatusOK, AccessRead, a.getSpec},
+		{http.MethodPut, "/dags/{name}/spec", "Update the definition of a DAG", DAGSpec{}, DAGSpec{}, http.StatusOK, AccessEdit, a.putSpec},
+		{http.MethodGet, "/dags/{name}/suspend", "Get the suspend state of a DAG", nil, SuspendState{}, http.StatusOK, AccessRead, a.getSuspend},
+		{http.MethodPut, "/dags/{name}/suspend", "Suspend or resume a DAG", SuspendState{}, SuspendState{}, http.StatusOK, AccessOperate, a.putSuspend},
+		{http.MethodGet, "/dags/{name}/status/stream", "Stream the status of a DAG as Server-Sent Events", nil, nil, http.StatusOK, AccessRead, a.streamStatus},
+		{http.MethodPost, "/dags/{name}/stop", "Stop the running DAG", nil, nil, http.StatusAccepted, AccessOperate, a.stopDAG},
+		{http.MethodGet, "/dags/{name}/runs", "List the recent runs of a DAG", nil, RunList{}, http.StatusOK, AccessRead, a.listRuns},
+		{http.MethodPost, "/dags/{name}/runs", "Start a new run of a DAG", StartRunRequest{}, RunAccepted{}, http.StatusAccepted, AccessOperate, a.startRun},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}", "Get a run", nil, models.Status{}, http.StatusOK, AccessRead, a.getRun},
+		{http.MethodPost, "/dags/{name}/runs/{requestId}/retry", "Retry a run", nil, RunAccepted{}, http.StatusAccepted, AccessOperate, a.retryRun},
+		{http.MethodPost, "/dags/{name}/runs/{requestId}/stop", "Stop a running run", nil, nil, http.StatusAccepted, AccessOperate, a.stopRun},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}/log", "Get the scheduler log of a run", nil, LogContent{}, http.StatusOK, AccessRead, a.getRunLog},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}/log/stream", "Stream the scheduler log of a run as Server-Sent Events", nil, nil, http.StatusOK, AccessRead, a.streamRunLog},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}/steps", "List the steps of a run", nil, StepList{}, http.StatusOK, AccessRead, a.listSteps},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}/steps/{step}", "Get a step of a run", nil, models.Node{}, http.StatusOK, AccessRead, a.getStep},
+		{http.MethodPatch, "/dags/{name}/runs/{requestId}/steps/{step}", "Change the status of a step", StepStatusRequest{}, models.Node{}, http.StatusOK, AccessEdit, a.patchStep},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}/steps/{step}/log", "Get the log of a step", nil, LogContent{}, http.StatusOK, AccessRead, a.getStepLog},
+		{http.MethodGet, "/dags/{name}/runs/{requestId}/steps/{step}/log/stream", "Stream the log of a step as Server-Sent Events", nil, nil, http.StatusOK, AccessRead, a.streamStepLog},
+		{http.MethodGet, "/dags/{name}/revisions", "List the revisions of a DAG", nil, RevisionList{}, http.StatusOK, AccessRead, a.listRevisions},
+		{http.MethodGet, "/dags/{name}/revisions/{hash}", "Get a revision of a DAG", nil, RevisionDetail{}, http.StatusOK, AccessRead, a.getRevision},
+		{http.MethodGet, "/dags/{name}/revisions/{hash}/diff", "Diff a revision with another one given by ?to= or the current definition", nil, RevisionDiff{}, http.StatusOK, AccessRead, a.diffRevision},
+		{http.MethodPost, "/dags/{name}/revisions/{hash}/rollback", "Roll back a DAG to a revision", nil, revision.Revision{}, http.StatusOK, AccessEdit, a.rollback},
+		{http.MethodGet, "/audit", "List the audit log entries", nil, AuditList{}, http.StatusOK, AccessAdmin, a.listAudit},
+	}
+	routes = append(routes, &APIRoute{
+		http.MethodGet, "/openapi.json", "Get the OpenAPI document", nil, nil, http.StatusOK, AccessRead,
+		func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+			renderJson(w, OpenAPIDocument(routes))
+		},
+	})
+	for _, route := range routes {
+		route.Path = APIPrefix + route.Path
+	}
+	return routes
+}
+
+type api struct {
+	cfg *APIConfig
+}
+
+func (a *api) listDAGs(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	dr := controller.NewDAGStatusReader()
+	dags, errs, err := dr.ReadAllStatus(a.cfg.DAGsDir)
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	ret := &DAGList{DAGs: []*DAGSummary{}, Errors: errs}
+	for _, d := range dags {
+		if allowed(r, d.DAG.Group, AccessRead) {
+			ret.DAGs = append(ret.DAGs, newDAGSummary(d))
+		}
+	}
+	writeAPIResponse(w, http.StatusOK, ret)
+}
+
+func (a *api) createDAG(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	req := &DAGName{}
+	if !readAPIRequest(w, r, req) {
+		return
+	}
+	if !validDAGName(req.Name) {
+		WriteAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid DAG name: %q", req.Name))
+		return
+	}
+	if !allowed(r, groupOf(req.Name, ""), AccessEdit) {
+		WriteAPIError(w, http.StatusForbidden, fmt.Errorf("forbidden to create %s", req.Name))
+		return
+	}
+	file := a.dagFile(req.Name)
+	if utils.FileExists(file) {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG %s already exists", req.Name))
+		return
+	}
+	if err := controller.CreateDAG(file); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, req.Name, "new", nil)
+	writeAPIResponse(w, http.StatusCreated, req)
+}
+
+func (a *api) getDAG(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &DAGDetail{
+		DAGSummary: newDAGSummary(d),
+		DAG:        d.DAG,
+		Revision:   revision.HashFile(d.DAG.Location),
+	})
+}
+
+func (a *api) deleteDAG(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	if d.Status != nil && d.Status.Status == scheduler.SchedulerStatus_Running {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG is running"))
+		return
+	}
+	old, _ := os.ReadFile(d.DAG.Location)
+	if err := controller.NewDAGController(d.DAG).DeleteDAG(); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	e := audit.NewEntry(r, p["name"], "delete", nil)
+	e.Diff = audit.Diff(string(old), "")
+	writeAudit(a.cfg.AuditLog, e)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *api) renameDAG(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	req := &DAGName{}
+	if !readAPIRequest(w, r, req) {
+		return
+	}
+	if !validDAGName(req.Name) {
+		WriteAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid DAG name: %q", req.Name))
+		return
+	}
+	file := a.dagFile(p["name"])
+	if !utils.FileExists(file) {
+		WriteAPIError(w, http.StatusNotFound, fmt.Errorf("DAG %s not found", p["name"]))
+		return
+	}
+	if !allowed(r, groupOf(req.Name, declaredGroup(file)), AccessEdit) {
+		WriteAPIError(w, http.StatusForbidden, fmt.Errorf("forbidden to rename to %s", req.Name))
+		return
+	}
+	newFile := a.dagFile(req.Name)
+	if utils.FileExists(newFile) {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG %s already exists", req.Name))
+		return
+	}
+	if err := controller.MoveDAG(file, newFile); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, p["name"], "rename", map[string]string{"newName": req.Name})
+	writeAPIResponse(w, http.StatusOK, req)
+}
+
+func (a *api) getSpec(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	file := a.dagFile(p["name"])
+	spec, err := dag.ReadFile(file)
+	if err != nil {
+		WriteAPIError(w, http.StatusNotFound, fmt.Errorf("DAG %s not found", p["name"]))
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &DAGSpec{Spec: spec})
+}
+
+func (a *api) putSpec(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	req := &DAGSpec{}
+	if !readAPIRequest(w, r, req) {
+		return
+	}
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	old, _ := os.ReadFile(d.DAG.Location)
+	rev, err := controller.NewDAGController(d.DAG).UpdateDAGSpecWithRevision(
+		req.Spec, audit.User(r.Context()), req.Message)
+	if err != nil {
+		WriteAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	e := audit.NewEntry(r, p["name"], "save", map[string]string{"revision": rev.Hash})
+	e.Diff = audit.Diff(string(old), req.Spec)
+	writeAudit(a.cfg.AuditLog, e)
+	writeAPIResponse(w, http.StatusOK, req)
+}
+
+func (a *api) getSuspend(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &SuspendState{Suspended: d.Suspended})
+}
+
+func (a *api) putSuspend(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	req := &SuspendState{}
+	if !readAPIRequest(w, r, req) {
+		return
+	}
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	sc := suspend.NewSuspendChecker(
+		storage.NewStorage(
+			settings.MustGet(
+				settings.SETTING__SUSPEND_FLAGS_DIR,
+			),
+		),
+	)
+	if err := sc.ToggleSuspend(d.DAG, req.Suspended); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, p["name"], "suspend", map[string]string{"suspend": strconv.FormatBool(req.Suspended)})
+	writeAPIResponse(w, http.StatusOK, req)
+}
+
+func (a *api) stopDAG(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	if d.Status == nil || d.Status.Status != scheduler.SchedulerStatus_Running {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG is not running"))
+		return
+	}
+	if err := controller.NewDAGController(d.DAG).Stop(); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, p["name"], "stop", nil)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (a *api) listRuns(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	limit := 30
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			WriteAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+	ret := &RunList{Runs: []*models.Status{}}
+	for _, f := range controller.NewDAGController(d.DAG).GetRecentStatuses(limit) {
+		ret.Runs = append(ret.Runs, f.Status)
+	}
+	writeAPIResponse(w, http.StatusOK, ret)
+}
+
+func (a *api) startRun(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	req := &StartRunRequest{}
+	if !readAPIRequest(w, r, req) {
+		return
+	}
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	if d.Error != nil {
+		WriteAPIError(w, http.StatusBadRequest, d.Error)
+		return
+	}
+	if isSkipped(d) {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG is already running"))
+		return
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	c := controller.NewDAGController(d.DAG)
+	go func() {
+		utils.LogErr("starting a DAG",
+			c.StartWithRequestId(a.cfg.Bin, a.cfg.WkDir, req.Params, id.String()))
+	}()
+	a.record(r, p["name"], "start", map[string]string{"params": req.Params, "newRequestId": id.String()})
+	writeAPIResponse(w, http.StatusAccepted, &RunAccepted{RequestId: id.String()})
+}
+
+func (a *api) getRun(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	status, ok := a.readRun(w, p)
+	if !ok {
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, status)
+}
+
+func (a *api) retryRun(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	if d.Status != nil && d.Status.Status == scheduler.SchedulerStatus_Running &&
+		d.DAG.OverlapPolicy != dag.OverlapAllowParallel {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG is already running"))
+		return
+	}
+	if _, ok := a.readRun(w, p); !ok {
+		return
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	c := controller.NewDAGController(d.DAG)
+	if err := c.RetryWithRequestId(a.cfg.Bin, a.cfg.WkDir, p["requestId"], id.String()); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, p["name"], "retry", map[string]string{"requestId": p["requestId"], "newRequestId": id.String()})
+	writeAPIResponse(w, http.StatusAccepted, &RunAccepted{RequestId: id.String()})
+}
+
+func (a *api) stopRun(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	status, ok := a.readRun(w, p)
+	if !ok {
+		return
+	}
+	if status.Status != scheduler.SchedulerStatus_Running {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("run %s is not running", p["requestId"]))
+		return
+	}
+	if err := a.dagController(p["name"]).StopRun(p["requestId"]); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, p["name"], "stop", map[string]string{"requestId": p["requestId"]})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// isSkipped returns whether a new run of the DAG is skipped because the
+// DAG is running and its overlap policy does not accept another run.
+func isSkipped(d *controller.DAGStatus) bool {
+	return d.Status != nil && d.Status.Status == scheduler.SchedulerStatus_Running &&
+		d.DAG.OverlapPolicy == dag.OverlapSkip
+}
+
+func (a *api) getRunLog(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	status, ok := a.readRun(w, p)
+	if !ok {
+		return
+	}
+	b, err := os.ReadFile(status.Log)
+	if err != nil {
+		WriteAPIError(w, http.StatusNotFound, fmt.Errorf("failed to read file %s", status.Log))
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &LogContent{File: status.Log, Content: string(b)})
+}
+
+func (a *api) listSteps(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	status, ok := a.readRun(w, p)
+	if !ok {
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &StepList{Steps: runSteps(status)})
+}
+
+func (a *api) getStep(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	_, step, ok := a.readStep(w, p)
+	if !ok {
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, step)
+}
+
+func (a *api) patchStep(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	req := &StepStatusRequest{}
+	if !readAPIRequest(w, r, req) {
+		return
+	}
+	to := scheduler.NodeStatus_None
+	switch req.Status {
+	case "success":
+		to = scheduler.NodeStatus_Success
+	case "failed":
+		to = scheduler.NodeStatus_Error
+	default:
+		WriteAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid status: %q", req.Status))
+		return
+	}
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	if d.Status != nil && d.Status.Status == scheduler.SchedulerStatus_Running {
+		WriteAPIError(w, http.StatusConflict, fmt.Errorf("DAG is running"))
+		return
+	}
+	status, step, ok := a.readStep(w, p)
+	if !ok {
+		return
+	}
+	step.Status = to
+	step.StatusText = to.String()
+	if err := controller.NewDAGController(d.DAG).UpdateStatus(status); err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.record(r, p["name"], "mark-"+req.Status, map[string]string{"requestId": p["requestId"], "step": p["step"]})
+	writeAPIResponse(w, http.StatusOK, step)
+}
+
+func (a *api) listRevisions(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	revs, err := controller.NewDAGController(d.DAG).GetRevisions()
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &RevisionList{Revisions: revs})
+}
+
+func (a *api) getRevision(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	rev, spec, ok := a.readRevision(w, d, p["hash"])
+	if !ok {
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &RevisionDetail{Revision: rev, Spec: spec})
+}
+
+func (a *api) diffRevision(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	from, fromSpec, ok := a.readRevision(w, d, p["hash"])
+	if !ok {
+		return
+	}
+	ret := &RevisionDiff{From: from.Hash}
+	toSpec := ""
+	if to := r.URL.Query().Get("to"); to != "" {
+		rev, spec, ok := a.readRevision(w, d, to)
+		if !ok {
+			return
+		}
+		ret.To, toSpec = rev.Hash, spec
+	} else {
+		b, err := os.ReadFile(d.DAG.Location)
+		if err != nil {
+			WriteAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		ret.To, toSpec = revision.Hash(b), string(b)
+	}
+	ret.Diff = audit.Diff(fromSpec, toSpec)
+	writeAPIResponse(w, http.StatusOK, ret)
+}
+
+func (a *api) rollback(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return
+	}
+	if _, _, ok := a.readRevision(w, d, p["hash"]); !ok {
+		return
+	}
+	old, _ := os.ReadFile(d.DAG.Location)
+	rev, err := controller.NewDAGController(d.DAG).Rollback(p["hash"], audit.User(r.Context()))
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	current, _ := os.ReadFile(d.DAG.Location)
+	e := audit.NewEntry(r, p["name"], "rollback", map[string]string{"revision": rev.Hash})
+	e.Diff = audit.Diff(string(old), string(current))
+	writeAudit(a.cfg.AuditLog, e)
+	writeAPIResponse(w, http.StatusOK, rev)
+}
+
+// readRevision reads the revision of the DAG and writes the error response
+// if not found.
+func (a *api) readRevision(w http.ResponseWriter, d *controller.DAGStatus, hash string) (*revision.Revision, string, bool) {
+	rev, spec, err := controller.NewDAGController(d.DAG).GetRevision(hash)
+	if errors.Is(err, revision.ErrRevisionNotFound) {
+		WriteAPIError(w, http.StatusNotFound, err)
+		return nil, "", false
+	}
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return nil, "", false
+	}
+	return rev, spec, true
+}
+
+func (a *api) listAudit(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		WriteAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	entries, err := a.cfg.AuditLog.Read(filter)
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &AuditList{Entries: entries})
+}
+
+func (a *api) getStepLog(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	_, step, ok := a.readStep(w, p)
+	if !ok {
+		return
+	}
+	var b []byte
+	var err error
+	if strings.ToLower(a.cfg.LogEncodingCharset) == "euc-jp" {
+		b, err = readFile(step.Log, japanese.EUCJP.NewDecoder())
+	} else {
+		b, err = os.ReadFile(step.Log)
+	}
+	if err != nil {
+		WriteAPIError(w, http.StatusNotFound, fmt.Errorf("failed to read file %s", step.Log))
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &LogContent{File: step.Log, Content: string(b)})
+}
+
+func (a *api) record(r *http.Request, dag, action string, params map[string]string) {
+	writeAudit(a.cfg.AuditLog, audit.NewEntry(r, dag, action, params))
+}
+
+func (a *api) dagFile(name string) string {
+	return filepath.Join(a.cfg.DAGsDir, nameWithExt(name))
+}
+
+// readDAG reads the DAG and writes the error response if not found.
+func (a *api) readDAG(w http.ResponseWriter, name string) (*controller.DAGStatus, bool) {
+	file := a.dagFile(name)
+	if !validDAGName(name) || !utils.FileExists(file) {
+		WriteAPIError(w, http.StatusNotFound, fmt.Errorf("DAG %s not found", name))
+		return nil, false
+	}
+	// a DAG with an invalid definition is returned with the error
+	d, _ := controller.NewDAGStatusReader().ReadStatusIn(a.cfg.DAGsDir, nameWithExt(name), false)
+	return d, true
+}
+
+// readRun reads the status of the run and writes the error response if not found.
+func (a *api) readRun(w http.ResponseWriter, p map[string]string) (*models.Status, bool) {
+	d, ok := a.readDAG(w, p["name"])
+	if !ok {
+		return nil, false
+	}
+	status, err := controller.NewDAGController(d.DAG).GetStatusByRequestId(p["requestId"])
+	if errors.Is(err, database.ErrRequestIdNotFound) {
+		WriteAPIError(w, http.StatusNotFound, fmt.Errorf("run %s not found", p["requestId"]))
+		return nil, false
+	}
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return status, true
+}
+
+// readStep reads the step of the run and writes the error response if not found.
+func (a *api) readStep(w http.ResponseWriter, p map[string]string) (*models.Status, *models.Node, bool) {
+	status, ok := a.readRun(w, p)
+	if !ok {
+		return nil, nil, false
+	}
+	for _, n := range runSteps(status) {
+		if n.Name == p["step"] {
+			return status, n, true
+		}
+	}
+	WriteAPIError(w, http.StatusNotFound, fmt.Errorf("step %s not found", p["step"]))
+	return nil, nil, false
+}
+
+func newDAGSummary(d *controller.DAGStatus) *DAGSummary {
+	ret := &DAGSummary{
+		Name:        dag.NameOf(d.File),
+		File:        d.File,
+		Group:       d.DAG.Group,
+		Description: d.DAG.Description,
+		Tags:        d.DAG.Tags,
+		Schedule:    []string{},
+		Timezone:    d.DAG.Timezone,
+		Suspended:   d.Suspended,
+		Status:      d.Status,
+		Queue:       d.Queue,
+		Running:     []string{},
+	}
+	for _, s := range d.Running {
+		ret.Running = append(ret.Running, s.RequestId)
+	}
+	for _, s := range d.DAG.Schedule {
+		ret.Schedule = append(ret.Schedule, s.Expression)
+	}
+	if d.Error != nil {
+		ret.Error = d.Error.Error()
+	}
+	return ret
+}
+
+// runSteps returns the steps of the run followed by the handlers.
+func runSteps(status *models.Status) []*models.Node {
+	ret := append([]*models.Node{}, status.Nodes...)
+	for _, n := range []*models.Node{
+		status.OnSuccess, status.OnFailure, status.OnCancel, status.OnExit,
+	} {
+		if n != nil && n.Step != nil && n.Name != "" {
+			ret = append(ret, n)
+		}
+	}
+	return ret
+}
+
+var dagNameMatcher = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// validDAGName returns whether the name is a valid name of a DAG, which is
+// the path of the DAG file relative to the DAGs directory. Hidden
+// directories are not allowed as they are not searched for DAGs.
+func validDAGName(name string) bool {
+	s := strings.Split(strings.TrimSuffix(name, ".yaml"), "/")
+	for i, v := range s {
+		if !dagNameMatcher.MatchString(v) || v == "." || v == ".." ||
+			(i < len(s)-1 && strings.HasPrefix(v, ".")) {
+			return false
+		}
+	}
+	return true
+}
+
+// readAPIRequest decodes the JSON request body and writes the error
+// response if it is invalid. An empty body is decoded as an empty object.
+func readAPIRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Body == nil {
+		return true
+	}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		WriteAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	utils.LogErr("write response", json.NewEncoder(w).Encode(data))
+}
+
+// WriteAPIError writes the error response of the REST API.
+func WriteAPIError(w http.ResponseWriter, status int, err error) {
+	code, ok := apiErrorCodes[status]
+	if !ok {
+		code = apiErrorInternal
+	}
+	writeAPIResponse(w, status, &APIError{Code: code, Message: err.Error()})
+}
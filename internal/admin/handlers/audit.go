@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/yohamta/dagu/internal/audit"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// AuditList is the list of the audit log entries, newest first.
+type AuditList struct {
+	Entries []*audit.Entry `json:"Entries"`
+}
+
+type AuditHandlerConfig struct {
+	AuditLog *audit.Logger
+}
+
+func HandleGetAudit(hc *AuditHandlerConfig, tc *TemplateConfig) http.HandlerFunc {
+	renderFunc := useTemplate("index.gohtml", "audit", tc)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isJsonRequest(r) {
+			renderFunc(w, nil)
+			return
+		}
+		filter, err := parseAuditFilter(r.URL.Query())
+		if err != nil {
+			encodeError(w, errInvalidArgs)
+			return
+		}
+		entries, err := hc.AuditLog.Read(filter)
+		if err != nil {
+			encodeError(w, err)
+			return
+		}
+		renderJson(w, &AuditList{Entries: entries})
+	}
+}
+
+const defaultAuditLimit = 100
+
+// parseAuditFilter parses the filter of the audit log entries from the
+// query parameters. since and until are RFC3339 timestamps or dates.
+func parseAuditFilter(q url.Values) (*audit.Filter, error) {
+	f := &audit.Filter{
+		DAG:    q.Get("dag"),
+		User:   q.Get("user"),
+		Action: q.Get("action"),
+		Limit:  defaultAuditLimit,
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit: %s", v)
+		}
+		f.Limit = n
+	}
+	for _, p := range []struct {
+		key string
+		val *time.Time
+	}{
+		{"since", &f.Since},
+		{"until", &f.Until},
+	} {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+		t, err := parseAuditTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", p.key, v)
+		}
+		*p.val = t
+	}
+	return f, nil
+}
+
+func parseAuditTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
+
+func writeAudit(l *audit.Logger, e *audit.Entry) {
+	if l == nil {
+		return
+	}
+	utils.LogErr("write audit log", l.Write(e))
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/yohamta/dagu/internal/dag"
+)
+
+// Authorizer returns whether the access to the DAGs in the group is allowed.
+type Authorizer func(group string, access Access) bool
+
+type authorizerKey struct{}
+
+// WithAuthorizer returns the context with the authorizer of the
+// authenticated user.
+func WithAuthorizer(ctx context.Context, a Authorizer) context.Context {
+	return context.WithValue(ctx, authorizerKey{}, a)
+}
+
+// allowed returns whether the request is allowed the access to the DAGs in
+// the group. Everything is allowed when authentication is disabled.
+func allowed(r *http.Request, group string, access Access) bool {
+	if a, ok := r.Context().Value(authorizerKey{}).(Authorizer); ok {
+		return a(group, access)
+	}
+	return true
+}
+
+// groupOf returns the group of the DAG of the name. The group of a DAG in
+// a subdirectory is the subdirectory, otherwise it is the group declared
+// in the DAG file.
+func groupOf(name, declared string) string {
+	if dir := path.Dir(strings.TrimSuffix(name, ".yaml")); dir != "." {
+		return dir
+	}
+	return declared
+}
+
+// declaredGroup returns the group declared in the DAG file, which becomes
+// the group of the DAG when it is moved to the top of the DAGs directory.
+func declaredGroup(file string) string {
+	cl := &dag.Loader{}
+	d, err := cl.LoadHeadOnly(file)
+	if err != nil {
+		return ""
+	}
+	return d.Group
+}
+
+// NewDAGName returns the name of the DAG the request is creating, given in
+// the JSON body of the API or the form of the page. The body of the API
+// request is restored so that the handler can read it again.
+func NewDAGName(r *http.Request) string {
+	if !strings.HasPrefix(r.URL.Path, APIPrefix) {
+		if r.FormValue("action") != "new" {
+			return ""
+		}
+		return r.FormValue("value")
+	}
+	if r.Body == nil {
+		return ""
+	}
+	b, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	req := &DAGName{}
+	if err != nil || json.Unmarshal(b, req) != nil {
+		return ""
+	}
+	return req.Name
+}
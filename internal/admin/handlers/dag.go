@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -12,15 +15,18 @@ import (
 	"strings"
 
 	"github.com/samber/lo"
+	"github.com/yohamta/dagu/internal/audit"
 	"github.com/yohamta/dagu/internal/constants"
 	"github.com/yohamta/dagu/internal/controller"
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/database"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/revision"
 	"github.com/yohamta/dagu/internal/scheduler"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/storage"
 	"github.com/yohamta/dagu/internal/suspend"
+	"github.com/yohamta/dagu/internal/utils"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/japanese"
 	"golang.org/x/text/transform"
@@ -29,6 +35,9 @@ import (
 type dagStatus struct {
 	Name string
 	Vals []scheduler.NodeStatus
+	// BackfillId is set for the row of the runs of a backfill. The values
+	// are the statuses of the runs.
+	BackfillId string
 }
 
 type Log struct {
@@ -51,22 +60,26 @@ type dagResponse struct {
 }
 
 type logFile struct {
-	Step    *models.Node
-	LogFile string
-	Content string
+	Step      *models.Node
+	LogFile   string
+	Content   string
+	RequestId string
 }
 
 const (
-	dag_TabType_Status  = "status"
-	dag_TabType_Spec    = "spec"
-	dag_TabType_History = "history"
-	dag_TabType_StepLog = "log"
-	dag_TabType_ScLog   = "scheduler-log"
+	dag_TabType_Status   = "status"
+	dag_TabType_Spec     = "spec"
+	dag_TabType_History  = "history"
+	dag_TabType_StepLog  = "log"
+	dag_TabType_ScLog    = "scheduler-log"
+	dag_TabType_Artifact = "artifact"
 )
 
 type dagParameter struct {
-	File string
-	Step string
+	File      string
+	Step      string
+	RequestId string
+	Name      string
 }
 
 func newDAGResponse(dagName string, dag *controller.DAGStatus, tab string) *dagResponse {
@@ -98,7 +111,7 @@ func HandleGetDAG(hc *DAGHandlerConfig, tc *TemplateConfig) http.HandlerFunc {
 		params := getDAGParameter(r)
 		file := filepath.Join(hc.DAGsDir, fmt.Sprintf("%s.yaml", dn))
 		dr := controller.NewDAGStatusReader()
-		d, err := dr.ReadStatus(file, false)
+		d, err := dr.ReadStatusIn(hc.DAGsDir, nameWithExt(dn), false)
 		if d == nil {
 			encodeError(w, err)
 			return
@@ -111,6 +124,16 @@ func HandleGetDAG(hc *DAGHandlerConfig, tc *TemplateConfig) http.HandlerFunc {
 
 		switch tab {
 		case dag_TabType_Status:
+			// the status of the run given by the request ID such as the
+			// run of a sub DAG started by a step
+			if params.RequestId != "" {
+				s, err := c.GetStatusByRequestId(params.RequestId)
+				if err != nil {
+					data.Errors = append(data.Errors, err.Error())
+				} else {
+					d.Status = s
+				}
+			}
 		case dag_TabType_Spec:
 			data.Definition, _ = dag.ReadFile(file)
 
@@ -136,6 +159,18 @@ func HandleGetDAG(hc *DAGHandlerConfig, tc *TemplateConfig) http.HandlerFunc {
 				}
 			}
 
+		case dag_TabType_Artifact:
+			f, err := c.GetArtifact(params.RequestId, params.Name)
+			if err != nil {
+				log.Printf("failed to get artifact: %v", err)
+				encodeError(w, errNotFound)
+				return
+			}
+			w.Header().Set("Content-Disposition",
+				fmt.Sprintf("attachment; filename=%q", filepath.Base(f)))
+			http.ServeFile(w, r, f)
+			return
+
 		default:
 		}
 
@@ -152,9 +187,10 @@ func isJsonRequest(r *http.Request) bool {
 }
 
 type PostDAGHandlerConfig struct {
-	DAGsDir string
-	Bin     string
-	WkDir   string
+	DAGsDir  string
+	Bin      string
+	WkDir    string
+	AuditLog *audit.Logger
 }
 
 func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
@@ -174,21 +210,25 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 
 		file := filepath.Join(hc.DAGsDir, fmt.Sprintf("%s.yaml", dn))
 		dr := controller.NewDAGStatusReader()
-		dag, err := dr.ReadStatus(file, false)
-		if err != nil && action != "save" {
+		dag, err := dr.ReadStatusIn(hc.DAGsDir, nameWithExt(dn), false)
+		if err != nil && action != "save" && action != "rollback" {
 			encodeError(w, err)
 			return
 		}
 		c := controller.NewDAGController(dag.DAG)
+		record := func(params map[string]string) {
+			writeAudit(hc.AuditLog, audit.NewEntry(r, dn, action, params))
+		}
 
 		switch action {
 		case "start":
-			if dag.Status.Status == scheduler.SchedulerStatus_Running {
+			if isSkipped(dag) {
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write([]byte("DAG is already running."))
 				return
 			}
 			c.StartAsync(hc.Bin, hc.WkDir, params)
+			record(map[string]string{"params": params})
 
 		case "suspend":
 			sc := suspend.NewSuspendChecker(
@@ -199,6 +239,7 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 				),
 			)
 			sc.ToggleSuspend(dag.DAG, value == "true")
+			record(map[string]string{"suspend": value})
 
 		case "stop":
 			if dag.Status.Status != scheduler.SchedulerStatus_Running {
@@ -206,12 +247,17 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 				w.Write([]byte("DAG is not running."))
 				return
 			}
-			err = c.Stop()
+			if reqId != "" {
+				err = c.StopRun(reqId)
+			} else {
+				err = c.Stop()
+			}
 			if err != nil {
 				w.WriteHeader(http.StatusNotFound)
 				w.Write([]byte(err.Error()))
 				return
 			}
+			record(nil)
 
 		case "retry":
 			if reqId == "" {
@@ -225,6 +271,7 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 				w.Write([]byte(err.Error()))
 				return
 			}
+			record(map[string]string{"requestId": reqId})
 
 		case "mark-success":
 			if dag.Status.Status == scheduler.SchedulerStatus_Running {
@@ -249,6 +296,7 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 				w.Write([]byte(err.Error()))
 				return
 			}
+			record(map[string]string{"requestId": reqId, "step": step})
 
 		case "mark-failed":
 			if dag.Status.Status == scheduler.SchedulerStatus_Running {
@@ -273,24 +321,57 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 				w.Write([]byte(err.Error()))
 				return
 			}
+			record(map[string]string{"requestId": reqId, "step": step})
 
 		case "save":
-			err := c.UpdateDAGSpec(value)
+			old, _ := os.ReadFile(file)
+			rev, err := c.UpdateDAGSpecWithRevision(value, audit.User(r.Context()), r.FormValue("message"))
 			if err != nil {
 				encodeError(w, err)
 				return
 			}
+			e := audit.NewEntry(r, dn, action, map[string]string{"revision": rev.Hash})
+			e.Diff = audit.Diff(string(old), value)
+			writeAudit(hc.AuditLog, e)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK"))
+			return
+
+		case "rollback":
+			old, _ := os.ReadFile(file)
+			rev, err := c.Rollback(value, audit.User(r.Context()))
+			if errors.Is(err, revision.ErrRevisionNotFound) {
+				encodeError(w, errNotFound)
+				return
+			}
+			if err != nil {
+				encodeError(w, err)
+				return
+			}
+			current, _ := os.ReadFile(file)
+			e := audit.NewEntry(r, dn, action, map[string]string{"revision": rev.Hash})
+			e.Diff = audit.Diff(string(old), string(current))
+			writeAudit(hc.AuditLog, e)
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 			return
 
 		case "rename":
+			if !validDAGName(value) {
+				encodeError(w, errInvalidArgs)
+				return
+			}
+			if !allowed(r, groupOf(value, declaredGroup(file)), AccessEdit) {
+				encodeError(w, errForbidden)
+				return
+			}
 			newfile := nameWithExt(path.Join(hc.DAGsDir, value))
 			err := controller.MoveDAG(file, newfile)
 			if err != nil {
 				encodeError(w, err)
 				return
 			}
+			record(map[string]string{"newName": value})
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 
@@ -304,7 +385,8 @@ func HandlePostDAG(hc *PostDAGHandlerConfig) http.HandlerFunc {
 }
 
 type DeleteDAGHandlerConfig struct {
-	DAGsDir string
+	DAGsDir  string
+	AuditLog *audit.Logger
 }
 
 func HandleDeleteDAG(hc *DeleteDAGHandlerConfig) http.HandlerFunc {
@@ -318,15 +400,19 @@ func HandleDeleteDAG(hc *DeleteDAGHandlerConfig) http.HandlerFunc {
 
 		file := filepath.Join(hc.DAGsDir, fmt.Sprintf("%s.yaml", dn))
 		dr := controller.NewDAGStatusReader()
-		dag, err := dr.ReadStatus(file, false)
+		dag, err := dr.ReadStatusIn(hc.DAGsDir, nameWithExt(dn), false)
 		c := controller.NewDAGController(dag.DAG)
 
+		old, _ := os.ReadFile(file)
 		err = c.DeleteDAG()
 
 		if err != nil {
 			encodeError(w, err)
 			return
 		}
+		e := audit.NewEntry(r, dn, "delete", nil)
+		e.Diff = audit.Diff(string(old), "")
+		writeAudit(hc.AuditLog, e)
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -354,27 +440,28 @@ func updateStatus(c *controller.DAGController, reqId, step string, to scheduler.
 }
 
 func readSchedulerLog(c *controller.DAGController, file string) (*logFile, error) {
-	f := ""
+	var s *models.Status
+	var err error
 	if file == "" {
-		s, err := c.GetLastStatus()
+		s, err = c.GetLastStatus()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read status")
 		}
-		f = s.Log
 	} else {
-		s, err := database.ParseFile(file)
+		s, err = database.ParseFile(file)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read status file %s", file)
 		}
-		f = s.Log
 	}
+	f := s.Log
 	b, err := os.ReadFile(f)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s", f)
 	}
 	return &logFile{
-		LogFile: f,
-		Content: string(b),
+		LogFile:   f,
+		Content:   string(b),
+		RequestId: s.RequestId,
 	}, nil
 }
 
@@ -386,27 +473,24 @@ func readStepLog(c *controller.DAGController, file, stepName, enc string) (*logF
 		constants.OnCancel:  nil,
 		constants.OnExit:    nil,
 	}
+	var status *models.Status
+	var err error
 	if file == "" {
-		s, err := c.GetLastStatus()
+		status, err = c.GetLastStatus()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read status")
 		}
-		steps = s.Nodes
-		stepm[constants.OnSuccess] = s.OnSuccess
-		stepm[constants.OnFailure] = s.OnFailure
-		stepm[constants.OnCancel] = s.OnCancel
-		stepm[constants.OnExit] = s.OnExit
 	} else {
-		s, err := database.ParseFile(file)
+		status, err = database.ParseFile(file)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read status file %s", file)
 		}
-		steps = s.Nodes
-		stepm[constants.OnSuccess] = s.OnSuccess
-		stepm[constants.OnFailure] = s.OnFailure
-		stepm[constants.OnCancel] = s.OnCancel
-		stepm[constants.OnExit] = s.OnExit
 	}
+	steps = status.Nodes
+	stepm[constants.OnSuccess] = status.OnSuccess
+	stepm[constants.OnFailure] = status.OnFailure
+	stepm[constants.OnCancel] = status.OnCancel
+	stepm[constants.OnExit] = status.OnExit
 	var step *models.Node = nil
 	for _, s := range steps {
 		if s.Name == stepName {
@@ -421,19 +505,22 @@ func readStepLog(c *controller.DAGController, file, stepName, enc string) (*logF
 		return nil, fmt.Errorf("step was not found %s", stepName)
 	}
 	var b []byte = nil
-	var err error = nil
-	if strings.ToLower(enc) == "euc-jp" {
+	switch {
+	case step.Log == "" || !utils.FileExists(step.Log):
+		// the step has not started yet and the log is streamed when it does
+	case strings.ToLower(enc) == "euc-jp":
 		b, err = readFile(step.Log, japanese.EUCJP.NewDecoder())
-	} else {
+	default:
 		b, err = os.ReadFile(step.Log)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s", step.Log)
 	}
 	return &logFile{
-		LogFile: step.Log,
-		Step:    step,
-		Content: string(b),
+		LogFile:   step.Log,
+		Step:      step,
+		Content:   string(b),
+		RequestId: status.RequestId,
 	}, nil
 }
 
@@ -462,7 +549,7 @@ func buildLog(logs []*models.StatusFile) *Log {
 			add(s, i)
 		}
 	}
-	grid := []*dagStatus{}
+	grid := backfillRows(logs)
 	for k, v := range tmp {
 		grid = append(grid, &dagStatus{Name: k, Vals: v})
 	}
@@ -498,19 +585,50 @@ func buildLog(logs []*models.StatusFile) *Log {
 	return ret
 }
 
+// backfillRows returns a row for each backfill of the runs with the statuses
+// of its runs, which are numbered in the same way as the statuses of steps.
+func backfillRows(logs []*models.StatusFile) []*dagStatus {
+	rows := []*dagStatus{}
+	byId := map[string]*dagStatus{}
+	for i, l := range logs {
+		id := l.Status.BackfillId
+		if id == "" {
+			continue
+		}
+		row, ok := byId[id]
+		if !ok {
+			row = &dagStatus{
+				Name:       fmt.Sprintf("backfill %s", utils.TruncString(id, 8)),
+				Vals:       make([]scheduler.NodeStatus, len(logs)),
+				BackfillId: id,
+			}
+			byId[id] = row
+			rows = append(rows, row)
+		}
+		row.Vals[i] = scheduler.NodeStatus(l.Status.Status)
+	}
+	return rows
+}
+
 var (
 	re  = regexp.MustCompile(`/dags/([^/\?]+)/([^/\?]+)/?`)
 	re2 = regexp.MustCompile(`/dags/([^/\?]+)/?`)
 )
 
+// getPathParameter returns the name of the DAG and the tab. The slashes in
+// the name of a DAG in a subdirectory are escaped in the URL.
 func getPathParameter(r *http.Request) (string, string, error) {
-	if m := re.FindStringSubmatch(r.URL.Path); m != nil {
-		return m[1], m[2], nil
+	name, tab := "", dag_TabType_Status
+	if m := re.FindStringSubmatch(r.URL.EscapedPath()); m != nil {
+		name, tab = m[1], m[2]
+	} else if m := re2.FindStringSubmatch(r.URL.EscapedPath()); m != nil {
+		name = m[1]
 	}
-	if m := re2.FindStringSubmatch(r.URL.Path); m != nil {
-		return m[1], dag_TabType_Status, nil
+	name, err := url.PathUnescape(name)
+	if err != nil || !validDAGName(name) {
+		return "", "", fmt.Errorf("invalid URL")
 	}
-	return "", "", fmt.Errorf("invalid URL")
+	return name, tab, nil
 }
 
 func getDAGParameter(r *http.Request) *dagParameter {
@@ -521,5 +639,11 @@ func getDAGParameter(r *http.Request) *dagParameter {
 	if step, ok := r.URL.Query()["step"]; ok {
 		p.Step = step[0]
 	}
+	if reqId, ok := r.URL.Query()["requestId"]; ok {
+		p.RequestId = reqId[0]
+	}
+	if name, ok := r.URL.Query()["name"]; ok {
+		p.Name = name[0]
+	}
 	return p
 }
@@ -11,6 +11,7 @@ import (
 var (
 	errInvalidArgs = errors.New("invalid argument")
 	errNotFound    = errors.New("not found")
+	errForbidden   = errors.New("forbidden")
 )
 
 func formatError(err error) string {
@@ -25,6 +26,8 @@ func encodeError(w http.ResponseWriter, err error) {
 		http.Error(w, formatError(err), http.StatusBadRequest)
 	case errNotFound:
 		http.Error(w, formatError(err), http.StatusNotFound)
+	case errForbidden:
+		http.Error(w, formatError(err), http.StatusForbidden)
 	default:
 		http.Error(w, formatError(err), http.StatusInternalServerError)
 	}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/yohamta/dagu/internal/audit"
 	"github.com/yohamta/dagu/internal/controller"
 )
 
@@ -19,7 +20,8 @@ type dagListResponse struct {
 }
 
 type DAGListHandlerConfig struct {
-	DAGsDir string
+	DAGsDir  string
+	AuditLog *audit.Logger
 }
 
 func HandleGetList(hc *DAGListHandlerConfig, tc *TemplateConfig) http.HandlerFunc {
@@ -27,11 +29,17 @@ func HandleGetList(hc *DAGListHandlerConfig, tc *TemplateConfig) http.HandlerFun
 	return func(w http.ResponseWriter, r *http.Request) {
 		dir := filepath.Join(hc.DAGsDir)
 		dr := controller.NewDAGStatusReader()
-		dags, errs, err := dr.ReadAllStatus(dir)
+		all, errs, err := dr.ReadAllStatus(dir)
 		if err != nil {
 			encodeError(w, err)
 			return
 		}
+		dags := []*controller.DAGStatus{}
+		for _, d := range all {
+			if allowed(r, d.DAG.Group, AccessRead) {
+				dags = append(dags, d)
+			}
+		}
 
 		hasErr := false
 		for _, j := range dags {
@@ -66,12 +74,21 @@ func HandlePostList(hc *DAGListHandlerConfig) http.HandlerFunc {
 
 		switch action {
 		case "new":
+			if !validDAGName(value) {
+				encodeError(w, errInvalidArgs)
+				return
+			}
+			if !allowed(r, groupOf(value, ""), AccessEdit) {
+				encodeError(w, errForbidden)
+				return
+			}
 			filename := nameWithExt(path.Join(hc.DAGsDir, value))
 			err := controller.CreateDAG(filename)
 			if err != nil {
 				encodeError(w, err)
 				return
 			}
+			writeAudit(hc.AuditLog, audit.NewEntry(r, strings.TrimSuffix(value, ".yaml"), action, nil))
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 			return
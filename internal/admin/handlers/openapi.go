@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yohamta/dagu/internal/constants"
+)
+
+// OpenAPIDocument generates the OpenAPI document of the routes. The schemas
+// of the request and response bodies are generated from their Go types.
+func OpenAPIDocument(routes []*APIRoute) map[string]interface{} {
+	g := &schemaGenerator{schemas: map[string]interface{}{}}
+	paths := map[string]map[string]interface{}{}
+	for _, route := range routes {
+		op := map[string]interface{}{
+			"summary":   route.Summary,
+			"responses": g.responses(route),
+		}
+		if params := pathParameters(route.Path); len(params) > 0 {
+			op["parameters"] = params
+		}
+		if route.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(g.schema(reflect.TypeOf(route.Request))),
+			}
+		}
+		if _, ok := paths[route.Path]; !ok {
+			paths[route.Path] = map[string]interface{}{}
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Dagu API",
+			"version": constants.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+		},
+	}
+}
+
+func (g *schemaGenerator) responses(route *APIRoute) map[string]interface{} {
+	ok := map[string]interface{}{
+		"description": http.StatusText(route.Status),
+	}
+	if route.Response != nil {
+		ok["content"] = jsonContent(g.schema(reflect.TypeOf(route.Response)))
+	}
+	return map[string]interface{}{
+		strconv.Itoa(route.Status): ok,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content":     jsonContent(g.schema(reflect.TypeOf(APIError{}))),
+		},
+	}
+}
+
+func pathParameters(p string) []interface{} {
+	ret := []interface{}{}
+	for _, m := range apiPathParam.FindAllStringSubmatch(p, -1) {
+		ret = append(ret, map[string]interface{}{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	return ret
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": schema,
+		},
+	}
+}
+
+// schemaGenerator generates JSON schemas of Go types. Named struct types
+// are added to the components and referred to by $ref.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// reserve the name first for recursive types
+			g.schemas[name] = map[string]interface{}{}
+			g.schemas[name] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	g.addProperties(t, props)
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+// addProperties adds the fields of the struct in the same way as encoding/json.
+func (g *schemaGenerator) addProperties(t reflect.Type, props map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addProperties(ft, props)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		switch ft.Kind() {
+		case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+	}
+}
+
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "handlers" || pkg == "" {
+		return t.Name()
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
@@ -21,11 +21,17 @@ func HandleGetSearch(DAGsDir string, tc *TemplateConfig) http.HandlerFunc {
 			return
 		}
 
-		ret, errs, err := controller.GrepDAG(DAGsDir, query[0])
+		all, errs, err := controller.GrepDAG(DAGsDir, query[0])
 		if err != nil {
 			encodeError(w, err)
 			return
 		}
+		ret := []*controller.GrepResult{}
+		for _, g := range all {
+			if allowed(r, g.DAG.Group, AccessRead) {
+				ret = append(ret, g)
+			}
+		}
 
 		resp := &searchResponse{
 			Results: ret,
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// LogChunk is a chunk of a log file sent by the log streams. Offset is the
+// byte offset in the log file at the end of the chunk, which is also sent
+// as the event ID so that a reconnecting client resumes from there.
+type LogChunk struct {
+	Offset  int64  `json:"Offset"`
+	Content string `json:"Content"`
+}
+
+var (
+	// streamInterval is the interval to poll the log files and the status.
+	streamInterval = time.Millisecond * 500
+	// streamChunkSize is the maximum size of a chunk read from a log file.
+	streamChunkSize = 64 * 1024
+)
+
+// sseWriter writes Server-Sent Events to the response.
+type sseWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported")
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	return &sseWriter{w: w, f: f}, nil
+}
+
+func (s *sseWriter) send(event, id string, data []byte) {
+	fmt.Fprintf(s.w, "event: %s\n", event)
+	if id != "" {
+		fmt.Fprintf(s.w, "id: %s\n", id)
+	}
+	fmt.Fprintf(s.w, "data: %s\n\n", data)
+	s.f.Flush()
+}
+
+func (s *sseWriter) sendJson(event, id string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		event, b = "error", []byte(fmt.Sprintf(`{"Message":%q}`, err.Error()))
+	}
+	s.send(event, id, b)
+}
+
+// logSource returns the path of the log file to stream and whether the run
+// writing it has finished.
+type logSource func() (file string, done bool, err error)
+
+func (a *api) streamRunLog(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	if _, ok := a.readRun(w, p); !ok {
+		return
+	}
+	c := a.dagController(p["name"])
+	a.streamLog(w, r, func() (string, bool, error) {
+		status, err := c.GetStatusByRequestId(p["requestId"])
+		if err != nil {
+			return "", false, err
+		}
+		return status.Log, runFinished(status), nil
+	})
+}
+
+func (a *api) streamStepLog(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	if _, _, ok := a.readStep(w, p); !ok {
+		return
+	}
+	c := a.dagController(p["name"])
+	a.streamLog(w, r, func() (string, bool, error) {
+		status, err := c.GetStatusByRequestId(p["requestId"])
+		if err != nil {
+			return "", false, err
+		}
+		for _, n := range runSteps(status) {
+			if n.Name == p["step"] {
+				return n.Log, runFinished(status), nil
+			}
+		}
+		return "", false, fmt.Errorf("step %s not found", p["step"])
+	})
+}
+
+// runFinished returns whether the run has finished. The status of a run is
+// none until the agent starts scheduling the steps.
+func runFinished(status *models.Status) bool {
+	return status.Status != scheduler.SchedulerStatus_None &&
+		status.Status != scheduler.SchedulerStatus_Running
+}
+
+// streamLog sends the content of the log file as "log" events while it
+// grows, starting from the offset given by the Last-Event-ID header or the
+// offset query parameter. It sends an "end" event when the run has finished
+// and the whole file has been sent.
+func (a *api) streamLog(w http.ResponseWriter, r *http.Request, src logSource) {
+	offset, err := streamOffset(r)
+	if err != nil {
+		WriteAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	sw, err := newSSEWriter(w)
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for {
+		file, done, err := src()
+		if err != nil {
+			sw.sendJson("error", "", &APIError{Code: apiErrorInternal, Message: err.Error()})
+			return
+		}
+		if file != "" {
+			content, n, err := a.readLogChunk(file, offset)
+			if err != nil {
+				sw.sendJson("error", "", &APIError{Code: apiErrorInternal, Message: err.Error()})
+				return
+			}
+			if n > 0 {
+				offset += n
+				sw.sendJson("log", strconv.FormatInt(offset, 10), &LogChunk{Offset: offset, Content: content})
+				continue
+			}
+		}
+		if done {
+			sw.sendJson("end", strconv.FormatInt(offset, 10), &LogChunk{Offset: offset})
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(streamInterval):
+		}
+	}
+}
+
+// streamStatus sends the status of the DAG as a "status" event whenever it
+// changes. The status of a running DAG is read from the socket of the agent.
+// The requestId query parameter selects the run when the DAG has more than
+// one running run.
+func (a *api) streamStatus(w http.ResponseWriter, r *http.Request, p map[string]string) {
+	if _, ok := a.readDAG(w, p["name"]); !ok {
+		return
+	}
+	sw, err := newSSEWriter(w)
+	if err != nil {
+		WriteAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	c := a.dagController(p["name"])
+	requestId := r.URL.Query().Get("requestId")
+	var last []byte
+	for {
+		var (
+			status *models.Status
+			err    error
+		)
+		if requestId != "" {
+			status, err = c.GetStatusByRequestId(requestId)
+		} else {
+			status, err = c.GetLastStatus()
+		}
+		if err == nil {
+			b, err := json.Marshal(status)
+			if err == nil && !bytes.Equal(b, last) {
+				sw.send("status", "", b)
+				last = b
+			}
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(streamInterval):
+		}
+	}
+}
+
+// dagController returns the controller of the DAG. It is used by the streams
+// that keep reading the status after the DAG has been checked to exist.
+func (a *api) dagController(name string) *controller.DAGController {
+	d, _ := controller.NewDAGStatusReader().ReadStatusIn(a.cfg.DAGsDir, nameWithExt(name), false)
+	return controller.NewDAGController(d.DAG)
+}
+
+// readLogChunk reads the log file from the offset and returns the decoded
+// content and the number of bytes read. A multi-byte character at the end
+// of the file is left to the next read until it is written completely.
+func (a *api) readLogChunk(file string, offset int64) (string, int64, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+	buf := make([]byte, streamChunkSize)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", 0, err
+	}
+	buf = buf[:n]
+	if strings.ToLower(a.cfg.LogEncodingCharset) == "euc-jp" {
+		dst := make([]byte, len(buf)*3)
+		nDst, nSrc, err := japanese.EUCJP.NewDecoder().Transform(dst, buf, false)
+		if err != nil && err != transform.ErrShortSrc {
+			return "", 0, err
+		}
+		return string(dst[:nDst]), int64(nSrc), nil
+	}
+	n = completeUTF8(buf)
+	return string(buf[:n]), int64(n), nil
+}
+
+// completeUTF8 returns the length of b without an incomplete UTF-8
+// character at the end.
+func completeUTF8(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
+
+func streamOffset(r *http.Request) (int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("offset")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	offset, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid offset: %q", v)
+	}
+	return offset, nil
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/yohamta/dagu/internal/utils"
 )
@@ -26,8 +27,14 @@ func NewServer(cfg *Config) *server {
 	}
 }
 
+// shutdownTimeout is the time to wait for the requests in progress to
+// finish when the server shuts down.
+var shutdownTimeout = time.Second * 10
+
 func (svr *server) Shutdown() {
-	err := svr.server.Shutdown(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := svr.server.Shutdown(ctx)
 	if err != nil {
 		log.Printf("server shutdown: %v", err)
 	}
@@ -63,20 +70,20 @@ func (svr *server) Serve() (err error) {
 }
 
 func (svr *server) setupServer() {
+	ctx, cancel := context.WithCancel(context.Background())
 	svr.server = &http.Server{
-		Addr: svr.addr,
+		Addr:        svr.addr,
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
+	// Shutdown does not cancel the requests in progress, so the contexts
+	// of the requests are canceled to end the streams of Server-Sent Events.
+	svr.server.RegisterOnShutdown(cancel)
 }
 
 func (svr *server) setupHandler() {
-	svr.admin.addRoute(http.MethodPost, `^/shutdown$`, svr.handleShutdown)
+	svr.admin.addRoute(&route{http.MethodPost, `^/shutdown$`, svr.handleShutdown, adminAccess})
 	handler := requestLogger(svr.admin)
 	handler = cors(handler)
-	if svr.config.IsBasicAuth {
-		handler = basicAuth(handler,
-			svr.config.BasicAuthUsername,
-			svr.config.BasicAuthPassword)
-	}
 	svr.server.Handler = handler
 }
 
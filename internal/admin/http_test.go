@@ -5,10 +5,13 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/settings"
 )
 
 func TestHttpServerStartShutdown(t *testing.T) {
@@ -75,6 +78,44 @@ func TestHttpServerShutdownWithAPI(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestHttpServerShutdownWithStream(t *testing.T) {
+	settings.ChangeHomeDir(testHomeDir)
+	defer settings.ChangeHomeDir(testdataDir)
+
+	dir := t.TempDir()
+	require.NoError(t, controller.CreateDAG(path.Join(dir, "stream.yaml")))
+
+	host := "127.0.0.1"
+	port := findPort(t)
+	server := NewServer(&Config{
+		Host: host,
+		Port: port,
+		DAGs: dir,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		err := server.Serve()
+		require.NoError(t, err)
+		close(done)
+	}()
+
+	time.Sleep(time.Millisecond * 300)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s:%s/api/v1/dags/stream/status/stream", host, port))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the server shuts down without waiting for the stream to be closed
+	go server.Shutdown()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("the server did not shut down")
+	}
+}
+
 func TestHttpServerBasicAuth(t *testing.T) {
 	dir, err := os.MkdirTemp("", "test_http_server")
 	require.NoError(t, err)
@@ -119,6 +160,43 @@ func TestHttpServerBasicAuth(t *testing.T) {
 	require.Equal(t, "200 OK", res.Status)
 }
 
+func TestHttpServerShutdownForbidden(t *testing.T) {
+	host := "127.0.0.1"
+	port := findPort(t)
+	server := NewServer(&Config{
+		Host: host,
+		Port: port,
+		DAGs: testHomeDir,
+		Users: []*Principal{
+			{Name: "bob", Secret: "bob", Role: RoleViewer, Groups: map[string]Role{"etl": RoleAdmin}},
+		},
+	})
+
+	go func() {
+		err := server.Serve()
+		require.NoError(t, err)
+	}()
+	defer server.Shutdown()
+
+	time.Sleep(time.Millisecond * 300)
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s:%s/shutdown", host, port), nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("bob", "bob")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	time.Sleep(time.Millisecond * 300)
+
+	req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s:%s", host, port), nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("bob", "bob")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
 func findPort(t *testing.T) string {
 	t.Helper()
 	ln, err := net.Listen("tcp", ":0")
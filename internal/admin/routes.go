@@ -4,12 +4,36 @@ import (
 	"net/http"
 
 	"github.com/yohamta/dagu/internal/admin/handlers"
+	"github.com/yohamta/dagu/internal/audit"
+	"github.com/yohamta/dagu/internal/settings"
 )
 
 type route struct {
 	method  string
 	pattern string
 	handler http.HandlerFunc
+	// access returns the access level required for the request.
+	access func(r *http.Request) handlers.Access
+}
+
+func accessOf(a handlers.Access) func(r *http.Request) handlers.Access {
+	return func(r *http.Request) handlers.Access { return a }
+}
+
+var (
+	readAccess  = accessOf(handlers.AccessRead)
+	editAccess  = accessOf(handlers.AccessEdit)
+	adminAccess = accessOf(handlers.AccessAdmin)
+)
+
+// postDAGAccess returns the access level required for the action of
+// HandlePostDAG.
+func postDAGAccess(r *http.Request) handlers.Access {
+	switch r.FormValue("action") {
+	case "start", "stop", "retry", "suspend":
+		return handlers.AccessOperate
+	}
+	return handlers.AccessEdit
 }
 
 func defaultRoutes(cfg *Config) []*route {
@@ -17,41 +41,57 @@ func defaultRoutes(cfg *Config) []*route {
 		NavbarColor: cfg.NavbarColor,
 		NavbarTitle: cfg.NavbarTitle,
 	}
-	return []*route{
+	al := audit.New(settings.MustGet(settings.SETTING__AUDIT_LOG))
+	routes := []*route{
 		{http.MethodGet, `^/?$`, handlers.HandleGetList(
 			&handlers.DAGListHandlerConfig{DAGsDir: cfg.DAGs},
 			tc,
-		)},
+		), readAccess},
 		{http.MethodPost, `^/?$`, handlers.HandlePostList(
-			&handlers.DAGListHandlerConfig{DAGsDir: cfg.DAGs},
-		)},
+			&handlers.DAGListHandlerConfig{DAGsDir: cfg.DAGs, AuditLog: al},
+		), editAccess},
 		{http.MethodGet, `^/dags/?$`, handlers.HandleGetList(
 			&handlers.DAGListHandlerConfig{DAGsDir: cfg.DAGs},
 			tc,
-		)},
+		), readAccess},
 		{http.MethodPost, `^/dags/?$`, handlers.HandlePostList(
-			&handlers.DAGListHandlerConfig{DAGsDir: cfg.DAGs},
-		)},
+			&handlers.DAGListHandlerConfig{DAGsDir: cfg.DAGs, AuditLog: al},
+		), editAccess},
 		{http.MethodGet, `^/dags/([^/]+)/?.*`, handlers.HandleGetDAG(
 			&handlers.DAGHandlerConfig{
 				DAGsDir:            cfg.DAGs,
 				LogEncodingCharset: cfg.LogEncodingCharset,
 			}, tc,
-		)},
+		), readAccess},
 		{http.MethodPost, `^/dags/([^/]+)$`, handlers.HandlePostDAG(
 			&handlers.PostDAGHandlerConfig{
-				DAGsDir: cfg.DAGs,
-				Bin:     cfg.Command,
-				WkDir:   cfg.WorkDir,
+				DAGsDir:  cfg.DAGs,
+				Bin:      cfg.Command,
+				WkDir:    cfg.WorkDir,
+				AuditLog: al,
 			},
-		)},
+		), postDAGAccess},
 		{http.MethodDelete, `^/dags/([^/]+)$`, handlers.HandleDeleteDAG(
 			&handlers.DeleteDAGHandlerConfig{
-				DAGsDir: cfg.DAGs,
+				DAGsDir:  cfg.DAGs,
+				AuditLog: al,
 			},
-		)},
-		{http.MethodGet, `^/search/?.*$`, handlers.HandleGetSearch(cfg.DAGs, tc)},
-		{http.MethodGet, `^/assets/js/.*$`, handlers.HandleGetAssets("/web")},
-		{http.MethodGet, `^/assets/css/.*$`, handlers.HandleGetAssets("/web")},
+		), editAccess},
+		{http.MethodGet, `^/search/?.*$`, handlers.HandleGetSearch(cfg.DAGs, tc), readAccess},
+		{http.MethodGet, `^/audit/?$`, handlers.HandleGetAudit(
+			&handlers.AuditHandlerConfig{AuditLog: al}, tc,
+		), adminAccess},
+		{http.MethodGet, `^/assets/js/.*$`, handlers.HandleGetAssets("/web"), readAccess},
+		{http.MethodGet, `^/assets/css/.*$`, handlers.HandleGetAssets("/web"), readAccess},
+	}
+	for _, r := range handlers.APIRoutes(&handlers.APIConfig{
+		DAGsDir:            cfg.DAGs,
+		Bin:                cfg.Command,
+		WkDir:              cfg.WorkDir,
+		LogEncodingCharset: cfg.LogEncodingCharset,
+		AuditLog:           al,
+	}) {
+		routes = append(routes, &route{r.Method, r.Pattern(), r.Handler(), accessOf(r.Access)})
 	}
+	return routes
 }
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a record of an action taken on the admin server.
+type Entry struct {
+	Timestamp  time.Time         `json:"Timestamp"`
+	RemoteAddr string            `json:"RemoteAddr"`
+	User       string            `json:"User"`
+	DAG        string            `json:"DAG"`
+	Action     string            `json:"Action"`
+	Params     map[string]string `json:"Params,omitempty"`
+	// Diff is the unified diff of the DAG definition for edits.
+	Diff string `json:"Diff,omitempty"`
+}
+
+// NewEntry creates an entry of the action on the DAG requested by r.
+func NewEntry(r *http.Request, dag, action string, params map[string]string) *Entry {
+	return &Entry{
+		Timestamp:  time.Now(),
+		RemoteAddr: r.RemoteAddr,
+		User:       User(r.Context()),
+		DAG:        dag,
+		Action:     action,
+		Params:     params,
+	}
+}
+
+// Filter is the condition of the entries to read.
+type Filter struct {
+	DAG    string
+	User   string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	// Limit is the maximum number of entries. Zero means no limit.
+	Limit int
+}
+
+func (f *Filter) match(e *Entry) bool {
+	switch {
+	case f.DAG != "" && f.DAG != e.DAG:
+		return false
+	case f.User != "" && f.User != e.User:
+		return false
+	case f.Action != "" && f.Action != e.Action:
+		return false
+	case !f.Since.IsZero() && e.Timestamp.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Timestamp.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Logger is an append-only audit log. Each entry is written as a line of JSON.
+type Logger struct {
+	file string
+	mu   sync.Mutex
+}
+
+// New creates a logger writing to the file.
+func New(file string) *Logger {
+	return &Logger{file: file}
+}
+
+// Write appends the entry to the log.
+func (l *Logger) Write(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.file), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Read returns the entries matching the filter, newest first.
+func (l *Logger) Read(filter *Filter) ([]*Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ret := []*Entry{}
+	f, err := os.Open(l.file)
+	if os.IsNotExist(err) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), maxEntrySize)
+	for s.Scan() {
+		e := &Entry{}
+		if err := json.Unmarshal(s.Bytes(), e); err != nil {
+			continue
+		}
+		if filter.match(e) {
+			ret = append(ret, e)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	if filter.Limit > 0 && len(ret) > filter.Limit {
+		ret = ret[:filter.Limit]
+	}
+	return ret, nil
+}
+
+const maxEntrySize = 16 * 1024 * 1024
+
+type userKey struct{}
+
+// WithUser returns the context with the authenticated user.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User returns the authenticated user of the context.
+func User(ctx context.Context) string {
+	if v, ok := ctx.Value(userKey{}).(string); ok {
+		return v
+	}
+	return ""
+}
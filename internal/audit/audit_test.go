@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	l := New(path.Join(t.TempDir(), "audit", "audit.log"))
+
+	ret, err := l.Read(&Filter{})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(ret))
+
+	now := time.Now()
+	for i, e := range []*Entry{
+		{Timestamp: now.Add(-time.Hour * 2), User: "alice", DAG: "a", Action: "start"},
+		{Timestamp: now.Add(-time.Hour), User: "bob", DAG: "b", Action: "stop"},
+		{Timestamp: now, User: "alice", DAG: "b", Action: "save", Diff: "@@ -1,1 +1,1 @@\n-a\n+b\n"},
+	} {
+		e.Params = map[string]string{"i": string(rune('0' + i))}
+		require.NoError(t, l.Write(e))
+	}
+
+	for _, test := range []struct {
+		Filter *Filter
+		Want   []string
+	}{
+		{&Filter{}, []string{"2", "1", "0"}},
+		{&Filter{Limit: 2}, []string{"2", "1"}},
+		{&Filter{User: "alice"}, []string{"2", "0"}},
+		{&Filter{DAG: "b"}, []string{"2", "1"}},
+		{&Filter{Action: "stop"}, []string{"1"}},
+		{&Filter{Since: now.Add(-time.Minute * 90)}, []string{"2", "1"}},
+		{&Filter{Until: now.Add(-time.Minute * 30)}, []string{"1", "0"}},
+		{&Filter{User: "carol"}, []string{}},
+	} {
+		ret, err := l.Read(test.Filter)
+		require.NoError(t, err)
+		got := []string{}
+		for _, e := range ret {
+			got = append(got, e.Params["i"])
+		}
+		require.Equal(t, test.Want, got)
+	}
+
+	ret, err = l.Read(&Filter{Action: "save"})
+	require.NoError(t, err)
+	require.Equal(t, "@@ -1,1 +1,1 @@\n-a\n+b\n", ret[0].Diff)
+}
+
+func TestNewEntry(t *testing.T) {
+	r := httptest.NewRequest("POST", "/dags/test", nil)
+	r = r.WithContext(WithUser(r.Context(), "alice"))
+
+	e := NewEntry(r, "test", "start", map[string]string{"params": "x"})
+	require.Equal(t, "alice", e.User)
+	require.Equal(t, r.RemoteAddr, e.RemoteAddr)
+	require.Equal(t, "test", e.DAG)
+	require.Equal(t, "start", e.Action)
+	require.False(t, e.Timestamp.IsZero())
+
+	require.Equal(t, "", User(httptest.NewRequest("GET", "/", nil).Context()))
+}
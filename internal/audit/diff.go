@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type edit struct {
+	op   byte
+	text string
+}
+
+// Diff returns the unified diff of the lines of the texts. It returns
+// an empty string if they are the same.
+func Diff(old, new string) string {
+	edits := diffLines(splitLines(old), splitLines(new))
+	var sb strings.Builder
+	for start := 0; start < len(edits); {
+		c := start
+		for c < len(edits) && edits[c].op == ' ' {
+			c++
+		}
+		if c == len(edits) {
+			break
+		}
+		hunkStart := c - diffContext
+		if hunkStart < start {
+			hunkStart = start
+		}
+		end := c
+		for {
+			e := end + 1
+			for e < len(edits) && edits[e].op != ' ' {
+				e++
+			}
+			k := e
+			for k < len(edits) && edits[k].op == ' ' {
+				k++
+			}
+			if k < len(edits) && k-e <= 2*diffContext {
+				end = k
+				continue
+			}
+			end = e + diffContext
+			if end > len(edits) {
+				end = len(edits)
+			}
+			break
+		}
+		writeHunk(&sb, edits, hunkStart, end)
+		start = end
+	}
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, edits []edit, start, end int) {
+	aStart, bStart := 1, 1
+	for _, e := range edits[:start] {
+		if e.op != '+' {
+			aStart++
+		}
+		if e.op != '-' {
+			bStart++
+		}
+	}
+	aLen, bLen := 0, 0
+	for _, e := range edits[start:end] {
+		if e.op != '+' {
+			aLen++
+		}
+		if e.op != '-' {
+			bLen++
+		}
+	}
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, e := range edits[start:end] {
+		sb.WriteByte(e.op)
+		sb.WriteString(e.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// maxDiffCells limits the size of the table of the longest common
+// subsequence so that diffing large texts does not take much memory.
+const maxDiffCells = 1 << 20
+
+// diffLines returns the edits from a to b based on the longest common
+// subsequence of the lines. The lines between the common prefix and suffix
+// are replaced as a whole if they are too many to compare.
+func diffLines(a, b []string) []edit {
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	q := 0
+	for q < len(a)-p && q < len(b)-p && a[len(a)-1-q] == b[len(b)-1-q] {
+		q++
+	}
+	ret := []edit{}
+	for _, l := range a[:p] {
+		ret = append(ret, edit{' ', l})
+	}
+	ma, mb := a[p:len(a)-q], b[p:len(b)-q]
+	if (len(ma)+1)*(len(mb)+1) <= maxDiffCells {
+		ret = append(ret, lcsEdits(ma, mb)...)
+	} else {
+		for _, l := range ma {
+			ret = append(ret, edit{'-', l})
+		}
+		for _, l := range mb {
+			ret = append(ret, edit{'+', l})
+		}
+	}
+	for _, l := range a[len(a)-q:] {
+		ret = append(ret, edit{' ', l})
+	}
+	return ret
+}
+
+func lcsEdits(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	ret := []edit{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ret = append(ret, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ret = append(ret, edit{'-', a[i]})
+			i++
+		default:
+			ret = append(ret, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ret = append(ret, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ret = append(ret, edit{'+', b[j]})
+	}
+	return ret
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	for _, test := range []struct {
+		Old, New, Want string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{"", "a\n", "@@ -0,0 +1,1 @@\n+a\n"},
+		{"a\n", "", "@@ -1,1 +0,0 @@\n-a\n"},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n",
+			"1\n2\n3\n4\nx\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n",
+			"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n" +
+				"@@ -13,3 +13,4 @@\n 13\n 14\n 15\n+16\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\nx\n3\n4\n5\n6\n7\ny\n",
+			"@@ -1,8 +1,8 @@\n 1\n-2\n+x\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n",
+		},
+	} {
+		require.Equal(t, test.Want, Diff(test.Old, test.New))
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	// the lines are replaced as a whole when they are too many to compare
+	var old, new, want strings.Builder
+	old.WriteString("head\n")
+	new.WriteString("head\n")
+	want.WriteString("@@ -1,2001 +1,2001 @@\n head\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&old, "a%d\n", i)
+		fmt.Fprintf(&new, "b%d\n", i)
+	}
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&want, "-a%d\n", i)
+	}
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&want, "+b%d\n", i)
+	}
+	require.Equal(t, want.String(), Diff(old.String(), new.String()))
+}
@@ -15,3 +15,11 @@ const (
 	TimeFormat = "2006-01-02 15:04:05"
 	TimeEmpty  = "-"
 )
+
+// ArtifactsDirEnv is the environment variable that holds the directory
+// to store the artifacts of a run.
+const ArtifactsDirEnv = "DAGU_ARTIFACTS_DIR"
+
+// ScheduledTimeEnv is the environment variable that holds the time a run
+// started by the scheduler is scheduled at, in RFC 3339 format.
+const ScheduledTimeEnv = "DAGU_SCHEDULED_TIME"
@@ -7,13 +7,15 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
-	"strings"
+	"sort"
 	"syscall"
 	"time"
 
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/database"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/queue"
+	"github.com/yohamta/dagu/internal/revision"
 	"github.com/yohamta/dagu/internal/scheduler"
 	"github.com/yohamta/dagu/internal/sock"
 	"github.com/yohamta/dagu/internal/utils"
@@ -27,7 +29,8 @@ type GrepResult struct {
 	Matches []*grep.Match
 }
 
-// GrepDAG returns all DAGs that contain the given string.
+// GrepDAG returns all DAGs in the directory and its subdirectories that
+// contain the given string.
 func GrepDAG(dir string, pattern string) (ret []*GrepResult, errs []string, err error) {
 	ret = []*GrepResult{}
 	errs = []string{}
@@ -37,7 +40,7 @@ func GrepDAG(dir string, pattern string) (ret []*GrepResult, errs []string, err
 			return
 		}
 	}
-	fis, err := os.ReadDir(dir)
+	files, err := dag.FindFiles(dir)
 	dl := &dag.Loader{}
 	opts := &grep.Options{
 		IsRegexp: true,
@@ -45,28 +48,23 @@ func GrepDAG(dir string, pattern string) (ret []*GrepResult, errs []string, err
 		After:    2,
 	}
 	utils.LogErr("read DAGs directory", err)
-	for _, fi := range fis {
-		if utils.MatchExtension(fi.Name(), dag.EXTENSIONS) {
-			fn := filepath.Join(dir, fi.Name())
-			utils.LogErr("read DAG file", err)
-			m, err := grep.Grep(fn, fmt.Sprintf("(?i)%s", pattern), opts)
-			if err != nil {
-				continue
-			} else if err != nil {
-				errs = append(errs, fmt.Sprintf("grep %s failed: %s", fi.Name(), err))
-				continue
-			}
-			dag, err := dl.LoadHeadOnly(fn)
-			if err != nil {
-				errs = append(errs, fmt.Sprintf("check %s failed: %s", fi.Name(), err))
-				continue
-			}
-			ret = append(ret, &GrepResult{
-				Name:    strings.TrimSuffix(fi.Name(), path.Ext(fi.Name())),
-				DAG:     dag,
-				Matches: m,
-			})
+	for _, f := range files {
+		fn := filepath.Join(dir, f)
+		m, err := grep.Grep(fn, fmt.Sprintf("(?i)%s", pattern), opts)
+		if err != nil {
+			continue
 		}
+		d, err := dl.LoadHeadOnly(fn)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("check %s failed: %s", f, err))
+			continue
+		}
+		d.SetDAGsDir(dir)
+		ret = append(ret, &GrepResult{
+			Name:    dag.NameOf(f),
+			DAG:     d,
+			Matches: m,
+		})
 	}
 	return ret, errs, nil
 }
@@ -86,6 +84,9 @@ func CreateDAG(file string) error {
 	if utils.FileExists(file) {
 		return fmt.Errorf("the config file %s already exists", file)
 	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
 	return os.WriteFile(file, []byte(_DAGTemplate), 0644)
 }
 
@@ -94,11 +95,17 @@ func MoveDAG(oldDAGPath, newDAGPath string) error {
 	if err := validateLocation(newDAGPath); err != nil {
 		return err
 	}
+	if err := os.MkdirAll(filepath.Dir(newDAGPath), 0755); err != nil {
+		return err
+	}
 	if err := os.Rename(oldDAGPath, newDAGPath); err != nil {
 		return err
 	}
 	db := database.New()
-	return db.MoveData(oldDAGPath, newDAGPath)
+	if err := db.MoveData(oldDAGPath, newDAGPath); err != nil {
+		return err
+	}
+	return revision.New().Move(oldDAGPath, newDAGPath)
 }
 
 // DAGController is a object to interact with a DAG.
@@ -112,8 +119,26 @@ func NewDAGController(d *dag.DAG) *DAGController {
 	}
 }
 
+// Stop stops all the running runs of the DAG.
 func (dc *DAGController) Stop() error {
-	client := sock.Client{Addr: dc.SockAddr()}
+	running, err := dc.GetRunningStatuses()
+	if err != nil {
+		return err
+	}
+	if len(running) == 0 {
+		return fmt.Errorf("the DAG is not running")
+	}
+	for _, s := range running {
+		if err := dc.StopRun(s.RequestId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StopRun stops the running run of the request ID.
+func (dc *DAGController) StopRun(requestId string) error {
+	client := sock.Client{Addr: dc.SockAddr(requestId)}
 	_, err := client.Request("POST", "/stop")
 	return err
 }
@@ -126,10 +151,57 @@ func (dc *DAGController) StartAsync(binPath string, workDir string, params strin
 }
 
 func (dc *DAGController) Start(binPath string, workDir string, params string) error {
+	return dc.StartWithRequestId(binPath, workDir, params, "")
+}
+
+// StartWithRequestId starts the DAG with the request ID given to the run.
+// A new request ID is generated by the agent when it is empty.
+func (dc *DAGController) StartWithRequestId(binPath, workDir, params, requestId string) error {
 	args := []string{"start"}
 	if params != "" {
 		args = append(args, fmt.Sprintf("--params=\"%s\"", params))
 	}
+	if requestId != "" {
+		args = append(args, fmt.Sprintf("--req=%s", requestId))
+	}
+	return dc.start(binPath, workDir, args)
+}
+
+// StartScheduled starts the DAG as the run scheduled at t. The time is given
+// to the run as DAGU_SCHEDULED_TIME.
+func (dc *DAGController) StartScheduled(binPath, workDir string, t time.Time) error {
+	args := []string{"start", fmt.Sprintf("--scheduled-time=%s", t.Format(time.RFC3339))}
+	return dc.start(binPath, workDir, args)
+}
+
+// StartBackfill starts the DAG as the run of the backfill scheduled at t
+// with the request ID.
+func (dc *DAGController) StartBackfill(binPath, workDir, requestId, backfillId string, t time.Time) error {
+	args := []string{"start",
+		fmt.Sprintf("--req=%s", requestId),
+		fmt.Sprintf("--scheduled-time=%s", t.Format(time.RFC3339)),
+		fmt.Sprintf("--backfill=%s", backfillId),
+	}
+	return dc.start(binPath, workDir, args)
+}
+
+// StartQueued starts the run taken from the queue of the DAG with the
+// request ID and the parameters it was queued with.
+func (dc *DAGController) StartQueued(binPath, workDir string, item *queue.Item) error {
+	args := []string{"start", fmt.Sprintf("--req=%s", item.RequestId)}
+	if item.Params != "" {
+		args = append(args, fmt.Sprintf("--params=\"%s\"", item.Params))
+	}
+	if item.ScheduledTime != "" {
+		args = append(args, fmt.Sprintf("--scheduled-time=%s", item.ScheduledTime))
+	}
+	if item.BackfillId != "" {
+		args = append(args, fmt.Sprintf("--backfill=%s", item.BackfillId))
+	}
+	return dc.start(binPath, workDir, args)
+}
+
+func (dc *DAGController) start(binPath, workDir string, args []string) error {
 	args = append(args, dc.Location)
 	cmd := exec.Command(binPath, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
@@ -143,9 +215,18 @@ func (dc *DAGController) Start(binPath string, workDir string, params string) er
 }
 
 func (dc *DAGController) Retry(binPath string, workDir string, reqId string) (err error) {
+	return dc.RetryWithRequestId(binPath, workDir, reqId, "")
+}
+
+// RetryWithRequestId retries the run of reqId as a new run of newReqId.
+// A new request ID is generated by the agent when newReqId is empty.
+func (dc *DAGController) RetryWithRequestId(binPath, workDir, reqId, newReqId string) (err error) {
 	go func() {
 		args := []string{"retry"}
 		args = append(args, fmt.Sprintf("--req=%s", reqId))
+		if newReqId != "" {
+			args = append(args, fmt.Sprintf("--new-req=%s", newReqId))
+		}
 		args = append(args, dc.Location)
 		cmd := exec.Command(binPath, args...)
 		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: 0}
@@ -172,27 +253,66 @@ func (dc *DAGController) Restart(bin string, workDir string) error {
 	return cmd.Wait()
 }
 
+// GetStatus returns the status of the latest running run of the DAG, or
+// the default status when the DAG is not running.
 func (dc *DAGController) GetStatus() (*models.Status, error) {
-	client := sock.Client{Addr: dc.SockAddr()}
-	ret, err := client.Request("GET", "/status")
+	running, err := dc.GetRunningStatuses()
+	if err != nil {
+		return nil, err
+	}
+	if len(running) == 0 {
+		return defaultStatus(dc.DAG), nil
+	}
+	return running[0], nil
+}
+
+// GetRunningStatuses returns the statuses of the running runs of the DAG,
+// the latest first. They are read from the sockets of the runs.
+func (dc *DAGController) GetRunningStatuses() ([]*models.Status, error) {
+	ret := []*models.Status{}
+	entries, err := os.ReadDir(dc.SockDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return ret, nil
+	}
 	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".sock" {
+			continue
+		}
+		status, err := requestStatus(path.Join(dc.SockDir(), e.Name()))
 		if errors.Is(err, sock.ErrTimeout) {
 			return nil, err
-		} else {
-			return defaultStatus(dc.DAG), nil
 		}
+		if err != nil {
+			// the socket is left by a process that has exited
+			continue
+		}
+		ret = append(ret, status)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].StartedAt > ret[j].StartedAt
+	})
+	return ret, nil
+}
+
+func requestStatus(addr string) (*models.Status, error) {
+	client := sock.Client{Addr: addr}
+	ret, err := client.Request("GET", "/status")
+	if err != nil {
+		return nil, err
 	}
 	return models.StatusFromJson(ret)
 }
 
 func (dc *DAGController) GetLastStatus() (*models.Status, error) {
-	client := sock.Client{Addr: dc.SockAddr()}
-	ret, err := client.Request("GET", "/status")
-	if err == nil {
-		return models.StatusFromJson(ret)
+	running, err := dc.GetRunningStatuses()
+	if err == nil && len(running) > 0 {
+		return running[0], nil
 	}
 
-	if err == nil || !errors.Is(err, sock.ErrTimeout) {
+	if err == nil {
 		db := database.New()
 		status, err := db.ReadStatusToday(dc.Location)
 		if err != nil {
@@ -210,18 +330,38 @@ func (dc *DAGController) GetLastStatus() (*models.Status, error) {
 	return nil, err
 }
 
+// GetStatusByRequestId returns the status of the run of the request ID. The
+// status of a running run is read from its socket.
 func (dc *DAGController) GetStatusByRequestId(requestId string) (*models.Status, error) {
 	db := database.New()
 	ret, err := db.FindByRequestId(dc.Location, requestId)
 	if err != nil {
 		return nil, err
 	}
-	status, _ := dc.GetStatus()
-	if status != nil && status.RequestId != requestId {
-		// if the request id is not matched then correct the status
+	status, err := requestStatus(dc.SockAddr(requestId))
+	if err == nil {
+		return status, nil
+	}
+	if !errors.Is(err, sock.ErrTimeout) {
+		// the run is not running if its socket does not respond
 		ret.Status.CorrectRunningStatus()
 	}
-	return ret.Status, err
+	return ret.Status, nil
+}
+
+// GetArtifact returns the path of the artifact file stored by the run.
+func (dc *DAGController) GetArtifact(requestId, name string) (string, error) {
+	status, err := dc.GetStatusByRequestId(requestId)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range status.Artifacts {
+		if a == name {
+			db := database.New()
+			return filepath.Join(db.ArtifactsDir(dc.Location, requestId), a), nil
+		}
+	}
+	return "", fmt.Errorf("artifact %s not found", name)
 }
 
 func (dc *DAGController) GetRecentStatuses(n int) []*models.StatusFile {
@@ -231,7 +371,7 @@ func (dc *DAGController) GetRecentStatuses(n int) []*models.StatusFile {
 }
 
 func (dc *DAGController) UpdateStatus(status *models.Status) error {
-	client := sock.Client{Addr: dc.SockAddr()}
+	client := sock.Client{Addr: dc.SockAddr(status.RequestId)}
 	res, err := client.Request("GET", "/status")
 	if err != nil {
 		if errors.Is(err, sock.ErrTimeout) {
@@ -258,17 +398,61 @@ func (dc *DAGController) UpdateStatus(status *models.Status) error {
 }
 
 func (dc *DAGController) UpdateDAGSpec(value string) error {
+	_, err := dc.UpdateDAGSpecWithRevision(value, "", "")
+	return err
+}
+
+// UpdateDAGSpecWithRevision updates the DAG file and saves the definition
+// as a new revision. The definition before the first update is also saved
+// so that it can be rolled back.
+func (dc *DAGController) UpdateDAGSpecWithRevision(value, author, message string) (*revision.Revision, error) {
 	// validate
 	cl := dag.Loader{}
 	_, err := cl.LoadData([]byte(value))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !utils.FileExists(dc.Location) {
-		return fmt.Errorf("the config file %s does not exist", dc.Location)
+		return nil, fmt.Errorf("the config file %s does not exist", dc.Location)
 	}
-	err = os.WriteFile(dc.Location, []byte(value), 0755)
-	return err
+	rs := revision.New()
+	revs, err := rs.List(dc.Location)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 {
+		old, err := os.ReadFile(dc.Location)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := rs.Save(dc.Location, old, "", "Initial revision"); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(dc.Location, []byte(value), 0644); err != nil {
+		return nil, err
+	}
+	return rs.Save(dc.Location, []byte(value), author, message)
+}
+
+// GetRevisions returns the revisions of the DAG, newest first.
+func (dc *DAGController) GetRevisions() ([]*revision.Revision, error) {
+	return revision.New().List(dc.Location)
+}
+
+// GetRevision returns the revision and its definition.
+func (dc *DAGController) GetRevision(hash string) (*revision.Revision, string, error) {
+	return revision.New().Get(dc.Location, hash)
+}
+
+// Rollback updates the DAG file to the definition of the revision.
+func (dc *DAGController) Rollback(hash, author string) (*revision.Revision, error) {
+	rev, spec, err := dc.GetRevision(hash)
+	if err != nil {
+		return nil, err
+	}
+	return dc.UpdateDAGSpecWithRevision(spec, author,
+		fmt.Sprintf("Rollback to %s", rev.Hash[:8]))
 }
 
 func (dc *DAGController) DeleteDAG() error {
@@ -277,6 +461,9 @@ func (dc *DAGController) DeleteDAG() error {
 	if err != nil {
 		return err
 	}
+	if err := revision.New().Remove(dc.Location); err != nil {
+		return err
+	}
 	return os.Remove(dc.Location)
 }
 
@@ -13,6 +13,7 @@ import (
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/database"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/revision"
 	"github.com/yohamta/dagu/internal/scheduler"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/sock"
@@ -40,31 +41,47 @@ func TestGetStatusRunningAndDone(t *testing.T) {
 
 	dc := controller.NewDAGController(ds.DAG)
 
-	socketServer, _ := sock.NewServer(
-		&sock.Config{
-			Addr: ds.DAG.SockAddr(),
-			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
-				status := models.NewStatus(
-					ds.DAG, []*scheduler.Node{},
-					scheduler.SchedulerStatus_Running, 0, nil, nil)
-				w.WriteHeader(http.StatusOK)
-				b, _ := status.ToJson()
-				w.Write(b)
-			},
-		})
-
-	go func() {
-		socketServer.Serve(nil)
-	}()
-
-	defer socketServer.Shutdown()
+	require.NoError(t, os.MkdirAll(ds.DAG.SockDir(), 0755))
+	servers := []*sock.Server{}
+	for _, requestId := range []string{"request-1", "request-2"} {
+		requestId := requestId
+		socketServer, _ := sock.NewServer(
+			&sock.Config{
+				Addr: ds.DAG.SockAddr(requestId),
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					status := models.NewStatus(
+						ds.DAG, []*scheduler.Node{},
+						scheduler.SchedulerStatus_Running, 0, nil, nil)
+					status.RequestId = requestId
+					w.WriteHeader(http.StatusOK)
+					b, _ := status.ToJson()
+					w.Write(b)
+				},
+			})
+		go func() {
+			socketServer.Serve(nil)
+		}()
+		defer socketServer.Shutdown()
+		servers = append(servers, socketServer)
+	}
 
 	time.Sleep(time.Millisecond * 100)
 	st, err := dc.GetStatus()
 	require.NoError(t, err)
 	require.Equal(t, scheduler.SchedulerStatus_Running, st.Status)
 
-	socketServer.Shutdown()
+	running, err := dc.GetRunningStatuses()
+	require.NoError(t, err)
+	require.Len(t, running, 2)
+
+	servers[0].Shutdown()
+
+	running, err = dc.GetRunningStatuses()
+	require.NoError(t, err)
+	require.Len(t, running, 1)
+	require.Equal(t, "request-2", running[0].RequestId)
+
+	servers[1].Shutdown()
 
 	st, err = dc.GetStatus()
 	require.NoError(t, err)
@@ -125,6 +142,36 @@ func TestUpdateStatus(t *testing.T) {
 	require.Equal(t, newStatus, statusByRequestId.Nodes[0].Status)
 }
 
+func TestGetArtifact(t *testing.T) {
+	var (
+		file      = testDAG("get_artifact.yaml")
+		requestId = "test-get-artifact"
+		dr        = controller.NewDAGStatusReader()
+		db        = &database.Database{Config: database.DefaultConfig()}
+	)
+
+	dag, err := dr.ReadStatus(file, false)
+	require.NoError(t, err)
+
+	dc := controller.NewDAGController(dag.DAG)
+
+	w, _, _ := db.NewWriter(dag.DAG.Location, time.Now(), requestId)
+	require.NoError(t, w.Open())
+
+	st := testNewStatus(dag.DAG, requestId,
+		scheduler.SchedulerStatus_Success, scheduler.NodeStatus_Success)
+	st.Artifacts = []string{"out.txt"}
+	require.NoError(t, w.Write(st))
+	w.Close()
+
+	f, err := dc.GetArtifact(requestId, "out.txt")
+	require.NoError(t, err)
+	require.Equal(t, path.Join(db.ArtifactsDir(dag.DAG.Location, requestId), "out.txt"), f)
+
+	_, err = dc.GetArtifact(requestId, "../update_status.yaml")
+	require.Error(t, err)
+}
+
 func TestUpdateStatusError(t *testing.T) {
 	var (
 		file      = testDAG("update_status_failed.yaml")
@@ -288,6 +335,66 @@ steps:
 	require.Equal(t, validDAG, string(b))
 }
 
+func TestRevisions(t *testing.T) {
+	tmpDir := utils.MustTempDir("controller-test-revisions")
+	defer os.RemoveAll(tmpDir)
+
+	loc := path.Join(tmpDir, "revisions.yaml")
+	require.NoError(t, controller.CreateDAG(loc))
+	initial, err := os.ReadFile(loc)
+	require.NoError(t, err)
+
+	dc := controller.NewDAGController(&dag.DAG{Name: "revisions", Location: loc})
+	revs, err := dc.GetRevisions()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(revs))
+
+	spec := `steps:
+  - name: "1"
+    command: "true"
+`
+	rev, err := dc.UpdateDAGSpecWithRevision(spec, "alice", "change the command")
+	require.NoError(t, err)
+	require.Equal(t, "alice", rev.Author)
+
+	// the definition before the first update is saved as well
+	revs, err = dc.GetRevisions()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revs))
+	require.Equal(t, rev.Hash, revs[0].Hash)
+	require.Equal(t, "change the command", revs[0].Message)
+	require.Equal(t, revision.Hash(initial), revs[1].Hash)
+
+	_, got, err := dc.GetRevision(rev.Hash)
+	require.NoError(t, err)
+	require.Equal(t, spec, got)
+
+	_, _, err = dc.GetRevision("invalid")
+	require.ErrorIs(t, err, revision.ErrRevisionNotFound)
+
+	// rollback to the initial definition
+	rolledBack, err := dc.Rollback(revs[1].Hash, "bob")
+	require.NoError(t, err)
+	require.Equal(t, revs[1].Hash, rolledBack.Hash)
+	require.Equal(t, "bob", rolledBack.Author)
+	b, err := os.ReadFile(loc)
+	require.NoError(t, err)
+	require.Equal(t, initial, b)
+
+	// the revisions are moved with the DAG
+	newLoc := path.Join(tmpDir, "revisions_renamed.yaml")
+	require.NoError(t, controller.MoveDAG(loc, newLoc))
+	dc = controller.NewDAGController(&dag.DAG{Name: "revisions_renamed", Location: newLoc})
+	revs, err = dc.GetRevisions()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revs))
+
+	require.NoError(t, dc.DeleteDAG())
+	revs, err = dc.GetRevisions()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(revs))
+}
+
 func TestRemove(t *testing.T) {
 	tmpDir := utils.MustTempDir("controller-test-remove")
 	defer os.RemoveAll(tmpDir)
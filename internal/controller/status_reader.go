@@ -7,6 +7,7 @@ import (
 
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/queue"
 	"github.com/yohamta/dagu/internal/scheduler"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/storage"
@@ -23,6 +24,10 @@ type DAGStatus struct {
 	Suspended bool
 	Error     error
 	ErrorT    *string
+	// Queue is the runs waiting for the running one to finish in order.
+	Queue []*queue.Item
+	// Running is the statuses of the running runs, the latest first.
+	Running []*models.Status
 }
 
 // DAGStatusReader is the struct to read DAGStatus.
@@ -40,7 +45,7 @@ func NewDAGStatusReader() *DAGStatusReader {
 	}
 }
 
-// ReadAllStatus reads all DAGStatus
+// ReadAllStatus reads all DAGStatus in the directory and its subdirectories.
 func (dr *DAGStatusReader) ReadAllStatus(DAGsDir string) (statuses []*DAGStatus, errs []string, err error) {
 	statuses = []*DAGStatus{}
 	errs = []string{}
@@ -50,17 +55,15 @@ func (dr *DAGStatusReader) ReadAllStatus(DAGsDir string) (statuses []*DAGStatus,
 			return
 		}
 	}
-	fis, err := os.ReadDir(DAGsDir)
+	files, err := dag.FindFiles(DAGsDir)
 	utils.LogErr("read DAGs directory", err)
-	for _, fi := range fis {
-		if utils.MatchExtension(fi.Name(), dag.EXTENSIONS) {
-			d, err := dr.ReadStatus(filepath.Join(DAGsDir, fi.Name()), true)
-			utils.LogErr("read DAG config", err)
-			if d != nil {
-				statuses = append(statuses, d)
-			} else {
-				errs = append(errs, fmt.Sprintf("reading %s failed: %s", fi.Name(), err))
-			}
+	for _, f := range files {
+		d, err := dr.ReadStatusIn(DAGsDir, f, true)
+		utils.LogErr("read DAG config", err)
+		if d != nil {
+			statuses = append(statuses, d)
+		} else {
+			errs = append(errs, fmt.Sprintf("reading %s failed: %s", f, err))
 		}
 	}
 	return statuses, errs, nil
@@ -68,6 +71,20 @@ func (dr *DAGStatusReader) ReadAllStatus(DAGsDir string) (statuses []*DAGStatus,
 
 // ReadStatus loads DAG from config file.
 func (dr *DAGStatusReader) ReadStatus(dagLocation string, headerOnly bool) (*DAGStatus, error) {
+	return dr.readStatus("", dagLocation, headerOnly)
+}
+
+// ReadStatusIn loads DAG from the config file given by the path relative
+// to the DAGs directory. File of the returned DAGStatus is the relative path.
+func (dr *DAGStatusReader) ReadStatusIn(DAGsDir, file string, headerOnly bool) (*DAGStatus, error) {
+	ret, err := dr.readStatus(DAGsDir, filepath.Join(DAGsDir, file), headerOnly)
+	if ret != nil {
+		ret.File = filepath.ToSlash(file)
+	}
+	return ret, err
+}
+
+func (dr *DAGStatusReader) readStatus(DAGsDir, dagLocation string, headerOnly bool) (*DAGStatus, error) {
 	var (
 		cl  = dag.Loader{}
 		d   *dag.DAG
@@ -81,13 +98,18 @@ func (dr *DAGStatusReader) ReadStatus(dagLocation string, headerOnly bool) (*DAG
 	}
 
 	if err != nil {
-		if d != nil {
-			return dr.newDAGStatus(d, defaultStatus(d), err), err
+		if d == nil {
+			d = &dag.DAG{Location: dagLocation}
+			d.Init()
+		}
+		if DAGsDir != "" {
+			d.SetDAGsDir(DAGsDir)
 		}
-		d := &dag.DAG{Location: dagLocation}
-		d.Init()
 		return dr.newDAGStatus(d, defaultStatus(d), err), err
 	}
+	if DAGsDir != "" {
+		d.SetDAGsDir(DAGsDir)
+	}
 
 	if !headerOnly {
 		if _, err := scheduler.NewExecutionGraph(d.Steps...); err != nil {
@@ -108,6 +130,14 @@ func (dr *DAGStatusReader) newDAGStatus(d *dag.DAG, s *models.Status, err error)
 		Status:    s,
 		Suspended: dr.suspendChecker.IsSuspended(d),
 		Error:     err,
+		Queue:     []*queue.Item{},
+		Running:   []*models.Status{},
+	}
+	if items, err := queue.New().List(d.Location); err == nil {
+		ret.Queue = items
+	}
+	if running, err := NewDAGController(d).GetRunningStatuses(); err == nil {
+		ret.Running = running
 	}
 	if err != nil {
 		errT := err.Error()
@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"os"
 	"path"
 	"path/filepath"
 	"testing"
@@ -37,6 +38,36 @@ func TestReadAll(t *testing.T) {
 	}
 }
 
+func TestReadAllSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"etl.yaml", "team-a/etl.yaml", "team-b/etl.yaml"} {
+		p := filepath.Join(dir, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("group: misc\nsteps:\n  - name: \"1\"\n    command: \"true\"\n"), 0644))
+	}
+	dr := controller.NewDAGStatusReader()
+	dags, errs, err := dr.ReadAllStatus(dir)
+	require.NoError(t, err)
+	require.Empty(t, errs)
+	require.Equal(t, 3, len(dags))
+
+	groups := map[string]string{}
+	for _, d := range dags {
+		groups[d.File] = d.DAG.Group
+	}
+	require.Equal(t, map[string]string{
+		"etl.yaml":        "misc",
+		"team-a/etl.yaml": "team-a",
+		"team-b/etl.yaml": "team-b",
+	}, groups)
+
+	ret, _, err := controller.GrepDAG(dir, "steps")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(ret))
+	require.Equal(t, "team-a/etl", ret[1].Name)
+	require.Equal(t, "team-a", ret[1].DAG.Group)
+}
+
 func TestReadDAGStatus(t *testing.T) {
 	var (
 		file = testDAG("read_status.yaml")
@@ -3,12 +3,12 @@ package dag
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mattn/go-shellwords"
-	"github.com/robfig/cron/v3"
 	"github.com/yohamta/dagu/internal/constants"
 	"github.com/yohamta/dagu/internal/utils"
 	"golang.org/x/sys/unix"
@@ -34,8 +34,6 @@ type buildStep struct {
 	Headline bool
 }
 
-var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-
 func (b *builder) buildFromDefinition(def *configDefinition, baseConfig *DAG) (d *DAG, err error) {
 	b.baseConfig = baseConfig
 
@@ -63,6 +61,18 @@ func (b *builder) buildFromDefinition(def *configDefinition, baseConfig *DAG) (d
 			BuildFn:  b.buildSchedule,
 			Headline: true,
 		},
+		{
+			BuildFn:  buildCatchup,
+			Headline: true,
+		},
+		{
+			BuildFn:  buildOverlapPolicy,
+			Headline: true,
+		},
+		{
+			BuildFn:  buildTriggers,
+			Headline: true,
+		},
 		{
 			BuildFn: b.buildEnvVariables,
 		},
@@ -108,6 +118,12 @@ const (
 )
 
 func (b *builder) buildSchedule(def *configDefinition, d *DAG) error {
+	if def.Timezone != "" {
+		if _, err := time.LoadLocation(def.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %s", def.Timezone)
+		}
+		d.Timezone = def.Timezone
+	}
 	starts := []string{}
 	stops := []string{}
 	restarts := []string{}
@@ -168,18 +184,109 @@ func (b *builder) buildSchedule(def *configDefinition, d *DAG) error {
 		return fmt.Errorf("invalid schedule type: %T", def.Schedule)
 	}
 	var err error
-	d.Schedule, err = parseSchedule(starts)
+	d.Schedule, err = parseSchedule(starts, d.Timezone)
 	if err != nil {
 		return err
 	}
-	d.StopSchedule, err = parseSchedule(stops)
+	d.StopSchedule, err = parseSchedule(stops, d.Timezone)
 	if err != nil {
 		return err
 	}
-	d.RestartSchedule, err = parseSchedule(restarts)
+	d.RestartSchedule, err = parseSchedule(restarts, d.Timezone)
 	return err
 }
 
+func buildCatchup(def *configDefinition, d *DAG) error {
+	switch p := CatchupPolicy(def.Catchup); p {
+	case "", CatchupNone:
+		d.Catchup = CatchupNone
+	case CatchupLatest, CatchupAll:
+		d.Catchup = p
+	default:
+		return fmt.Errorf("invalid catchup policy: %s", def.Catchup)
+	}
+	d.CatchupWindow = DefaultCatchupWindow
+	if def.CatchupWindowSec > 0 {
+		d.CatchupWindow = time.Second * time.Duration(def.CatchupWindowSec)
+	}
+	return nil
+}
+
+func buildOverlapPolicy(def *configDefinition, d *DAG) error {
+	switch p := OverlapPolicy(def.OverlapPolicy); p {
+	case "", OverlapSkip:
+		d.OverlapPolicy = OverlapSkip
+	case OverlapQueue, OverlapCancelPrevious, OverlapAllowParallel:
+		d.OverlapPolicy = p
+	default:
+		return fmt.Errorf("invalid overlap policy: %s", def.OverlapPolicy)
+	}
+	if def.MaxQueueLength < 0 {
+		return fmt.Errorf("maxQueueLength must be 0 or greater")
+	}
+	d.MaxQueueLength = def.MaxQueueLength
+	return nil
+}
+
+func buildTriggers(def *configDefinition, d *DAG) error {
+	for _, t := range def.Triggers {
+		if t.DAG != nil && t.File != nil {
+			return fmt.Errorf("trigger cannot specify both dag and file")
+		}
+		if t.File != nil {
+			ft, err := buildFileTrigger(t.File)
+			if err != nil {
+				return err
+			}
+			d.Triggers = append(d.Triggers, &Trigger{File: ft})
+			continue
+		}
+		if t.DAG == nil {
+			return fmt.Errorf("trigger must specify dag or file")
+		}
+		if t.DAG.Name == "" {
+			return fmt.Errorf("trigger dag name must be specified")
+		}
+		status, err := parseRunStatus(t.DAG.Status)
+		if err != nil {
+			return err
+		}
+		d.Triggers = append(d.Triggers, &Trigger{
+			DAG: &DAGTrigger{Name: t.DAG.Name, Status: status},
+		})
+	}
+	return nil
+}
+
+func buildFileTrigger(def *fileTriggerDef) (*FileTrigger, error) {
+	if def.Path == "" {
+		return nil, fmt.Errorf("trigger file path must be specified")
+	}
+	p := os.ExpandEnv(def.Path)
+	if _, err := filepath.Match(p, ""); err != nil {
+		return nil, fmt.Errorf("invalid trigger file path %s: %w", def.Path, err)
+	}
+	if def.StableSec < 0 {
+		return nil, fmt.Errorf("trigger file stableSec must not be negative")
+	}
+	return &FileTrigger{
+		Path:   p,
+		Stable: time.Second * time.Duration(def.StableSec),
+	}, nil
+}
+
+// parseRunStatus returns the status of a run to wait for. It is success
+// if not specified.
+func parseRunStatus(value string) (RunStatus, error) {
+	switch s := RunStatus(value); s {
+	case "":
+		return RunStatusSuccess, nil
+	case RunStatusSuccess, RunStatusFailed, RunStatusCanceled:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid run status: %s", value)
+}
+
 func (b *builder) buildEnvVariables(def *configDefinition, d *DAG) (err error) {
 	var env map[string]string
 	env, err = b.loadVariables(def.Env, b.defaultEnv)
@@ -346,7 +453,7 @@ func (b *builder) loadVariables(strVariables interface{}, defaults map[string]st
 
 	vars := map[string]string{}
 	for _, v := range vals {
-		parsed, err := utils.ParseVariable(v.val)
+		parsed, err := utils.ParseEnvVariable(v.val)
 		if err != nil {
 			return nil, err
 		}
@@ -362,12 +469,21 @@ func (b *builder) loadVariables(strVariables interface{}, defaults map[string]st
 }
 
 func (b *builder) buildStepsFromDefinition(def *configDefinition, d *DAG) error {
+	if def.TimeoutSec < 0 {
+		return fmt.Errorf("timeoutSec must not be negative")
+	}
 	ret := []*Step{}
 	for _, stepDef := range def.Steps {
 		step, err := b.buildStep(d.Env, stepDef)
 		if err != nil {
 			return err
 		}
+		if step.Timeout == 0 {
+			step.Timeout = time.Second * time.Duration(def.TimeoutSec)
+		}
+		if step.Pool == "" {
+			step.Pool = def.Pool
+		}
 		ret = append(ret, step)
 	}
 	d.Steps = ret
@@ -406,6 +522,18 @@ func (b *builder) buildStep(variables []string, def *stepDef) (*Step, error) {
 			return nil, fmt.Errorf("invalid executor config")
 		}
 	}
+	if def.Run != "" {
+		if def.Executor == nil {
+			step.ExecutorConfig.Type = ExecutorTypeDAG
+		}
+		step.Run = def.Run
+		step.Params = def.Params
+	}
+	if def.WaitFor != nil {
+		if err := buildWaitFor(def.WaitFor, step); err != nil {
+			return nil, err
+		}
+	}
 	// TODO: validate executor config
 	step.Variables = variables
 	step.Depends = def.Depends
@@ -414,9 +542,19 @@ func (b *builder) buildStep(variables []string, def *stepDef) (*Step, error) {
 		step.ContinueOn.Failure = def.ContinueOn.Failure
 	}
 	if def.RetryPolicy != nil {
+		if def.RetryPolicy.Backoff != 0 && def.RetryPolicy.Backoff < 1 {
+			return nil, fmt.Errorf("retryPolicy backoff must be 1 or greater")
+		}
+		if def.RetryPolicy.Jitter < 0 || def.RetryPolicy.Jitter > 1 {
+			return nil, fmt.Errorf("retryPolicy jitter must be between 0 and 1")
+		}
 		step.RetryPolicy = &RetryPolicy{
-			Limit:    def.RetryPolicy.Limit,
-			Interval: time.Second * time.Duration(def.RetryPolicy.IntervalSec),
+			Limit:       def.RetryPolicy.Limit,
+			Interval:    time.Second * time.Duration(def.RetryPolicy.IntervalSec),
+			Backoff:     def.RetryPolicy.Backoff,
+			MaxInterval: time.Second * time.Duration(def.RetryPolicy.MaxIntervalSec),
+			Jitter:      def.RetryPolicy.Jitter,
+			ExitCodes:   append(def.RetryPolicy.ExitCodes, def.RetryPolicy.RetryOn...),
 		}
 	}
 	if def.RepeatPolicy != nil {
@@ -433,9 +571,65 @@ func (b *builder) buildStep(variables []string, def *stepDef) (*Step, error) {
 	}
 	step.MailOnError = def.MailOnError
 	step.Preconditions = loadPreCondition(def.Preconditions)
+	step.Timeout = time.Second * time.Duration(def.TimeoutSec)
+	step.Pool = def.Pool
+	if len(def.When) > 0 {
+		step.When = loadPreCondition(def.When)
+	}
+	if def.Parallel != nil {
+		parallel, err := buildParallelConfig(def.Parallel)
+		if err != nil {
+			return nil, err
+		}
+		step.Parallel = parallel
+	}
 	return step, nil
 }
 
+func buildParallelConfig(def interface{}) (*ParallelConfig, error) {
+	cfg := &ParallelConfig{}
+	items := def
+	if m, ok := def.(map[interface{}]interface{}); ok {
+		items = nil
+		for k, v := range m {
+			switch k {
+			case "items":
+				items = v
+			case "maxConcurrent":
+				n, ok := v.(int)
+				if !ok || n < 0 {
+					return nil, fmt.Errorf("invalid maxConcurrent for parallel: %v", v)
+				}
+				cfg.MaxConcurrent = n
+			default:
+				return nil, fmt.Errorf("invalid key for parallel: %v", k)
+			}
+		}
+	}
+	switch val := items.(type) {
+	case []interface{}:
+		seen := map[string]bool{}
+		for _, v := range val {
+			item := fmt.Sprint(v)
+			if seen[item] {
+				return nil, fmt.Errorf("duplicate item for parallel: %s", item)
+			}
+			seen[item] = true
+			cfg.Items = append(cfg.Items, item)
+		}
+	case string:
+		// a reference to an output variable such as "$ITEMS" or "${ITEMS}"
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(val, "$"), "{"), "}")
+		if name == "" || !strings.HasPrefix(val, "$") {
+			return nil, fmt.Errorf("invalid variable for parallel: %s", val)
+		}
+		cfg.Variable = name
+	default:
+		return nil, fmt.Errorf("items for parallel must be a list or a variable")
+	}
+	return cfg, nil
+}
+
 func (b *builder) expandEnv(val string) string {
 	if b.noEval {
 		return val
@@ -482,10 +676,21 @@ func buildConfigEnv(vars map[string]string) []string {
 func loadPreCondition(cond []*conditionDef) []*Condition {
 	ret := []*Condition{}
 	for _, v := range cond {
-		ret = append(ret, &Condition{
-			Condition: v.Condition,
-			Expected:  v.Expected,
-		})
+		c := &Condition{
+			Condition:  v.Condition,
+			Expected:   v.Expected,
+			Not:        v.Not,
+			FileExists: v.FileExists,
+			Command:    v.Command,
+			ExitCode:   v.ExitCode,
+		}
+		if len(v.All) > 0 {
+			c.All = loadPreCondition(v.All)
+		}
+		if len(v.Any) > 0 {
+			c.Any = loadPreCondition(v.Any)
+		}
+		ret = append(ret, c)
 	}
 	return ret
 }
@@ -502,27 +707,46 @@ func parseTags(value string) []string {
 	return ret
 }
 
-func parseSchedule(values []string) ([]*Schedule, error) {
-	ret := []*Schedule{}
-	for _, v := range values {
-		paresed, err := cronParser.Parse(v)
-		if err != nil {
-			return nil, fmt.Errorf("invalid schedule: %s", err)
-		}
-		ret = append(ret, &Schedule{
-			Expression: v,
-			Parsed:     paresed,
-		})
+func buildWaitFor(def *waitForDef, step *Step) error {
+	if def.DAG == "" {
+		return fmt.Errorf("waitFor dag must be specified")
+	}
+	status, err := parseRunStatus(def.Status)
+	if err != nil {
+		return err
+	}
+	if def.PokeIntervalSec < 0 || def.TimeoutSec < 0 {
+		return fmt.Errorf("waitFor pokeIntervalSec and timeoutSec must not be negative")
+	}
+	step.ExecutorConfig.Type = ExecutorTypeWaitFor
+	step.WaitFor = &WaitFor{
+		DAG:          def.DAG,
+		Status:       status,
+		SameDate:     def.SameDate,
+		PokeInterval: time.Second * time.Duration(def.PokeIntervalSec),
+		Timeout:      time.Second * time.Duration(def.TimeoutSec),
 	}
-	return ret, nil
+	if step.WaitFor.PokeInterval == 0 {
+		step.WaitFor.PokeInterval = DefaultPokeInterval
+	}
+	return nil
 }
 
 func assertStepDef(def *stepDef) error {
 	if def.Name == "" {
 		return fmt.Errorf("step name must be specified")
 	}
-	if def.Command == "" {
+	if def.Command == "" && def.Run == "" && def.WaitFor == nil {
 		return fmt.Errorf("step command must be specified")
 	}
+	if def.Command != "" && def.Run != "" {
+		return fmt.Errorf("step command and run cannot be specified at the same time")
+	}
+	if def.WaitFor != nil && (def.Command != "" || def.Run != "") {
+		return fmt.Errorf("step waitFor cannot be specified with command or run")
+	}
+	if def.TimeoutSec < 0 {
+		return fmt.Errorf("step timeoutSec must not be negative")
+	}
 	return nil
 }
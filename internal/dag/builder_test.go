@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/yohamta/dagu/internal/settings"
@@ -28,6 +29,14 @@ steps:
   - name: step 1`,
 			expectedError: "step command must be specified",
 		},
+		{
+			input: `
+steps:
+  - name: step 1
+    command: echo 1
+    run: sub`,
+			expectedError: "step command and run cannot be specified at the same time",
+		},
 		{
 			input: fmt.Sprintf(`
 env: 
@@ -40,7 +49,7 @@ env:
 		},
 		{
 			input:         `schedule: "1"`,
-			expectedError: "invalid schedule: expected exactly 5 fields",
+			expectedError: "invalid schedule: expected 5 to 6 fields",
 		},
 	}
 
@@ -346,7 +355,9 @@ schedule:
 
 func TestGeneratingSockAddr(t *testing.T) {
 	d := &DAG{Location: "testdata/testDag.yml"}
-	require.Regexp(t, `^/tmp/@dagu-testDag-[0-9a-f]+\.sock$`, d.SockAddr())
+	require.Regexp(t, `^/tmp/@dagu-testDag-[0-9a-f]+$`, d.SockDir())
+	require.Regexp(t, `^/tmp/@dagu-testDag-[0-9a-f]+/[0-9a-f]{16}\.sock$`, d.SockAddr("request-id"))
+	require.NotEqual(t, d.SockAddr("request-id"), d.SockAddr("another"))
 }
 
 func TestOverwriteGlobalConfig(t *testing.T) {
@@ -454,3 +465,347 @@ steps:
 		require.Equal(t, step.SignalOnStop, tc.want)
 	}
 }
+
+func TestBuildingSubDAG(t *testing.T) {
+	dat := `name: test DAG
+steps:
+  - name: "1"
+    run: sub_dag
+    params: "A B"
+`
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(dat))
+	require.NoError(t, err)
+
+	step := ret.Steps[0]
+	require.Equal(t, ExecutorTypeDAG, step.ExecutorConfig.Type)
+	require.Equal(t, "sub_dag", step.Run)
+	require.Equal(t, "A B", step.Params)
+}
+
+func TestBuildingParallel(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  *ParallelConfig
+		err   bool
+	}{
+		{
+			input: `[a, b, 1]`,
+			want:  &ParallelConfig{Items: []string{"a", "b", "1"}},
+		},
+		{
+			input: `"${ITEMS}"`,
+			want:  &ParallelConfig{Variable: "ITEMS"},
+		},
+		{
+			input: `{items: $ITEMS, maxConcurrent: 2}`,
+			want:  &ParallelConfig{Variable: "ITEMS", MaxConcurrent: 2},
+		},
+		{
+			input: `ITEMS`,
+			err:   true,
+		},
+		{
+			input: `{items: [a], invalid: 1}`,
+			err:   true,
+		},
+		{
+			input: `[a, b, a]`,
+			err:   true,
+		},
+	} {
+		dat := fmt.Sprintf(`steps:
+  - name: "1"
+    command: "true"
+    parallel: %s
+`, tc.input)
+		l := &Loader{}
+		ret, err := l.LoadData([]byte(dat))
+		if tc.err {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.want, ret.Steps[0].Parallel)
+	}
+}
+
+func TestBuildingTimeout(t *testing.T) {
+	dat := `timeoutSec: 10
+steps:
+  - name: "1"
+    command: "true"
+  - name: "2"
+    command: "true"
+    timeoutSec: 5
+`
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(dat))
+	require.NoError(t, err)
+	require.Equal(t, time.Second*10, ret.Steps[0].Timeout)
+	require.Equal(t, time.Second*5, ret.Steps[1].Timeout)
+
+	_, err = l.LoadData([]byte(`steps:
+  - name: "1"
+    command: "true"
+    timeoutSec: -1
+`))
+	require.Error(t, err)
+
+	_, err = l.LoadData([]byte(`timeoutSec: -1
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.Error(t, err)
+}
+
+func TestBuildingPool(t *testing.T) {
+	dat := `pool: warehouse
+steps:
+  - name: "1"
+    command: "true"
+  - name: "2"
+    command: "true"
+    pool: api
+`
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(dat))
+	require.NoError(t, err)
+	require.Equal(t, "warehouse", ret.Steps[0].Pool)
+	require.Equal(t, "api", ret.Steps[1].Pool)
+}
+
+func TestBuildingRetryPolicy(t *testing.T) {
+	dat := `steps:
+  - name: "1"
+    command: "true"
+    retryPolicy:
+      limit: 3
+      intervalSec: 1
+      backoff: 2
+      maxIntervalSec: 10
+      jitter: 0.1
+      exitCodes: [75]
+`
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(dat))
+	require.NoError(t, err)
+	require.Equal(t, &RetryPolicy{
+		Limit:       3,
+		Interval:    time.Second,
+		Backoff:     2,
+		MaxInterval: time.Second * 10,
+		Jitter:      0.1,
+		ExitCodes:   []int{75},
+	}, ret.Steps[0].RetryPolicy)
+
+	ret, err = l.LoadData([]byte(`steps:
+  - name: "1"
+    command: "true"
+    retryPolicy:
+      limit: 3
+      retryOn: [75]
+`))
+	require.NoError(t, err)
+	require.Equal(t, []int{75}, ret.Steps[0].RetryPolicy.ExitCodes)
+
+	for _, invalid := range []string{"backoff: 0.5", "jitter: 2"} {
+		_, err = l.LoadData([]byte(fmt.Sprintf(`steps:
+  - name: "1"
+    command: "true"
+    retryPolicy:
+      limit: 3
+      %s
+`, invalid)))
+		require.Error(t, err)
+	}
+}
+
+func TestBuildingPreconditions(t *testing.T) {
+	dat := `steps:
+  - name: "1"
+    command: "true"
+    preconditions:
+      - condition: "$COUNT"
+        expected: "> 0"
+        not: true
+      - any:
+          - fileExists: /tmp/ready
+          - command: check.sh
+            exitCode: 1
+`
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(dat))
+	require.NoError(t, err)
+	require.Equal(t, []*Condition{
+		{
+			Condition: "$COUNT",
+			Expected:  "> 0",
+			Not:       true,
+		},
+		{
+			Any: []*Condition{
+				{FileExists: "/tmp/ready"},
+				{Command: "check.sh", ExitCode: 1},
+			},
+		},
+	}, ret.Steps[0].Preconditions)
+}
+
+func TestBuildingWhen(t *testing.T) {
+	dat := `steps:
+  - name: "1"
+    command: "true"
+    when:
+      - condition: "$MODE"
+        expected: "full"
+  - name: "2"
+    command: "true"
+`
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(dat))
+	require.NoError(t, err)
+	require.Equal(t, []*Condition{{Condition: "$MODE", Expected: "full"}}, ret.Steps[0].When)
+	require.Nil(t, ret.Steps[1].When)
+}
+
+func TestBuildingCatchup(t *testing.T) {
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(`steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	require.Equal(t, CatchupNone, ret.Catchup)
+	require.Equal(t, DefaultCatchupWindow, ret.CatchupWindow)
+
+	ret, err = l.LoadData([]byte(`catchup: all
+catchupWindowSec: 3600
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	require.Equal(t, CatchupAll, ret.Catchup)
+	require.Equal(t, time.Hour, ret.CatchupWindow)
+
+	_, err = l.LoadData([]byte(`catchup: some
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.Error(t, err)
+}
+
+func TestBuildingOverlapPolicy(t *testing.T) {
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(`steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	require.Equal(t, OverlapSkip, ret.OverlapPolicy)
+	require.Equal(t, 0, ret.MaxQueueLength)
+
+	ret, err = l.LoadData([]byte(`overlapPolicy: queue
+maxQueueLength: 3
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	require.Equal(t, OverlapQueue, ret.OverlapPolicy)
+	require.Equal(t, 3, ret.MaxQueueLength)
+
+	ret, err = l.LoadData([]byte(`overlapPolicy: allow-parallel
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	require.Equal(t, OverlapAllowParallel, ret.OverlapPolicy)
+
+	for _, dat := range []string{
+		`overlapPolicy: wait`,
+		`maxQueueLength: -1`,
+	} {
+		_, err = l.LoadData([]byte(dat + `
+steps:
+  - name: "1"
+    command: "true"
+`))
+		require.Error(t, err)
+	}
+}
+
+func TestBuildingWaitFor(t *testing.T) {
+	l := &Loader{}
+	ret, err := l.LoadData([]byte(`steps:
+  - name: "1"
+    waitFor:
+      dag: upstream
+      sameDate: true
+      timeoutSec: 3600
+  - name: "2"
+    waitFor:
+      dag: other
+      status: failed
+      pokeIntervalSec: 10
+`))
+	require.NoError(t, err)
+	require.Equal(t, ExecutorTypeWaitFor, ret.Steps[0].ExecutorConfig.Type)
+	require.Equal(t, &WaitFor{
+		DAG:          "upstream",
+		Status:       RunStatusSuccess,
+		SameDate:     true,
+		PokeInterval: DefaultPokeInterval,
+		Timeout:      time.Hour,
+	}, ret.Steps[0].WaitFor)
+	require.Equal(t, &WaitFor{
+		DAG:          "other",
+		Status:       RunStatusFailed,
+		PokeInterval: time.Second * 10,
+	}, ret.Steps[1].WaitFor)
+
+	for _, invalid := range []string{
+		`waitFor: {status: success}`,
+		`waitFor: {dag: upstream, status: done}`,
+		`waitFor: {dag: upstream, timeoutSec: -1}`,
+		`{waitFor: {dag: upstream}, command: "true"}`,
+	} {
+		_, err := l.LoadData([]byte(`steps:
+  - name: "1"
+    ` + invalid + `
+`))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestBuildingTriggers(t *testing.T) {
+	l := &Loader{}
+	ret, err := l.LoadHeadOnly(path.Join(testdataDir, "triggers.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, []*Trigger{
+		{DAG: &DAGTrigger{Name: "extract", Status: RunStatusSuccess}},
+		{DAG: &DAGTrigger{Name: "load", Status: RunStatusFailed}},
+		{File: &FileTrigger{Path: "/inbound/*.csv", Stable: time.Second * 30}},
+	}, ret.Triggers)
+
+	for _, invalid := range []string{
+		`triggers: [{dag: {status: success}}]`,
+		`triggers: [{dag: {name: extract, status: done}}]`,
+		`triggers: [{}]`,
+		`triggers: [{file: {stableSec: 30}}]`,
+		`triggers: [{file: {path: "/inbound/[.csv"}}]`,
+		`triggers: [{file: {path: /inbound/*.csv, stableSec: -1}}]`,
+		`triggers: [{dag: {name: extract}, file: {path: /inbound/*.csv}}]`,
+	} {
+		_, err := l.LoadData([]byte(invalid + `
+steps:
+  - name: "1"
+    command: "true"
+`))
+		require.Error(t, err, invalid)
+	}
+}
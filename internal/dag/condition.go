@@ -1,15 +1,32 @@
 package dag
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/yohamta/dagu/internal/utils"
 )
 
 // Condition represents a condition to be evaluated by the agent.
+//
+// Expected is compared with the evaluated Condition as a string by default.
+// It can be a regular expression prefixed by "re:", or a numeric
+// comparison such as "> 0". FileExists and Command check the file and the
+// exit code of the command instead. All and Any combine other conditions.
 type Condition struct {
-	Condition string
-	Expected  string
+	Condition  string
+	Expected   string
+	Not        bool
+	FileExists string
+	Command    string
+	ExitCode   int
+	All        []*Condition
+	Any        []*Condition
 }
 
 // ConditionResult represents an evaluated result of a condition.
@@ -32,20 +49,166 @@ func (c *Condition) Eval() (*ConditionResult, error) {
 	}, nil
 }
 
+// ErrConditionNotMet is returned when a condition is evaluated but not met.
+var ErrConditionNotMet = errors.New("condition was not met")
+
 // EvalCondition evaluates a single condition.
 func EvalCondition(c *Condition) error {
+	err := c.eval()
+	if c.Not {
+		switch {
+		case err == nil:
+			return fmt.Errorf("%w. Not %s", ErrConditionNotMet, c.describe())
+		case errors.Is(err, ErrConditionNotMet):
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *Condition) eval() error {
+	switch {
+	case len(c.All) > 0:
+		return EvalConditions(c.All)
+	case len(c.Any) > 0:
+		errs := []string{}
+		for _, cc := range c.Any {
+			err := EvalCondition(cc)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%w. None of the conditions was met: %s",
+			ErrConditionNotMet, strings.Join(errs, "; "))
+	case c.FileExists != "":
+		file := os.ExpandEnv(c.FileExists)
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("%w. File does not exist: %s", ErrConditionNotMet, file)
+		}
+		return nil
+	case c.Command != "":
+		return c.evalCommand()
+	}
 	r, err := c.Eval()
 	if err != nil {
 		return fmt.Errorf(
 			"failed to evaluate condition. Condition=%s Error=%v",
 			c.Condition, err)
 	}
-	if r.Expected != r.Actual {
+	ok, err := match(r.Expected, r.Actual)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to evaluate condition. Condition=%s Error=%v",
+			c.Condition, err)
+	}
+	if !ok {
 		return fmt.Errorf(
-			"condition was not met. Condition=%s Expected=%s Actual=%s",
-			r.Condition, r.Expected, r.Actual)
+			"%w. Condition=%s Expected=%s Actual=%s",
+			ErrConditionNotMet, r.Condition, r.Expected, r.Actual)
 	}
-	return err
+	return nil
+}
+
+func (c *Condition) evalCommand() error {
+	prog, args := utils.SplitCommand(c.Command, true)
+	code := 0
+	if err := exec.Command(prog, args...).Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf(
+				"failed to evaluate condition. Command=%s Error=%v",
+				c.Command, err)
+		}
+		code = exitErr.ExitCode()
+	}
+	if code != c.ExitCode {
+		return fmt.Errorf(
+			"%w. Command=%s ExpectedExitCode=%d ActualExitCode=%d",
+			ErrConditionNotMet, c.Command, c.ExitCode, code)
+	}
+	return nil
+}
+
+func (c *Condition) describe() string {
+	switch {
+	case len(c.All) > 0:
+		return "All conditions"
+	case len(c.Any) > 0:
+		return "Any condition"
+	case c.FileExists != "":
+		return fmt.Sprintf("FileExists=%s", c.FileExists)
+	case c.Command != "":
+		return fmt.Sprintf("Command=%s ExitCode=%d", c.Command, c.ExitCode)
+	}
+	return fmt.Sprintf("Condition=%s Expected=%s", c.Condition, c.Expected)
+}
+
+var numericOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// match compares the actual value with the expected value.
+func match(expected, actual string) (bool, error) {
+	if strings.HasPrefix(expected, "re:") {
+		re, err := regexp.Compile(strings.TrimPrefix(expected, "re:"))
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(actual), nil
+	}
+	for _, op := range numericOperators {
+		if !strings.HasPrefix(expected, op) {
+			continue
+		}
+		want, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(expected, op)), 64)
+		if err != nil {
+			// not a numeric comparison
+			break
+		}
+		got, err := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+		if err != nil {
+			return false, nil
+		}
+		switch op {
+		case ">=":
+			return got >= want, nil
+		case "<=":
+			return got <= want, nil
+		case "==":
+			return got == want, nil
+		case "!=":
+			return got != want, nil
+		case ">":
+			return got > want, nil
+		case "<":
+			return got < want, nil
+		}
+	}
+	return expected == actual, nil
+}
+
+// Expand returns a copy of the condition with the variables in it
+// replaced by the mapping.
+func (c *Condition) Expand(mapping func(string) string) *Condition {
+	ret := *c
+	ret.Condition = os.Expand(c.Condition, mapping)
+	ret.Expected = os.Expand(c.Expected, mapping)
+	ret.FileExists = os.Expand(c.FileExists, mapping)
+	ret.Command = os.Expand(c.Command, mapping)
+	ret.All = ExpandConditions(c.All, mapping)
+	ret.Any = ExpandConditions(c.Any, mapping)
+	return &ret
+}
+
+// ExpandConditions expands the variables in a list of conditions.
+func ExpandConditions(cond []*Condition, mapping func(string) string) []*Condition {
+	if cond == nil {
+		return nil
+	}
+	ret := []*Condition{}
+	for _, c := range cond {
+		ret = append(ret, c.Expand(mapping))
+	}
+	return ret
 }
 
 // EvalConditions evaluates a list of conditions.
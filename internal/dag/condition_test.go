@@ -80,3 +80,66 @@ func TestConditionsWithEval(t *testing.T) {
 		require.Equal(t, tt.isErr, err != nil)
 	}
 }
+
+func TestConditionExpressions(t *testing.T) {
+	file, err := os.CreateTemp("", "condition_test")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	tests := []struct {
+		condition *Condition
+		met       bool
+	}{
+		{&Condition{Condition: "`echo foobar`", Expected: "re:^foo"}, true},
+		{&Condition{Condition: "`echo foobar`", Expected: "re:^bar"}, false},
+		{&Condition{Condition: "`echo 10`", Expected: "> 0"}, true},
+		{&Condition{Condition: "`echo 10`", Expected: "<=9.5"}, false},
+		{&Condition{Condition: "`echo 10`", Expected: "== 10"}, true},
+		{&Condition{Condition: "`echo abc`", Expected: "> 0"}, false},
+		{&Condition{Condition: ">x", Expected: ">x"}, true},
+		{&Condition{Condition: "`echo 1`", Expected: "1", Not: true}, false},
+		{&Condition{Condition: "`echo 1`", Expected: "2", Not: true}, true},
+		{&Condition{FileExists: file.Name()}, true},
+		{&Condition{FileExists: file.Name() + ".none"}, false},
+		{&Condition{Command: "true"}, true},
+		{&Condition{Command: "sh -c 'exit 3'", ExitCode: 3}, true},
+		{&Condition{Command: "false"}, false},
+		{&Condition{All: []*Condition{
+			{Condition: "`echo 1`", Expected: "1"},
+			{Command: "false"},
+		}}, false},
+		{&Condition{Any: []*Condition{
+			{Condition: "`echo 1`", Expected: "2"},
+			{Command: "true"},
+		}}, true},
+		{&Condition{Not: true, Any: []*Condition{
+			{Command: "false"},
+			{FileExists: file.Name() + ".none"},
+		}}, true},
+	}
+
+	for i, tt := range tests {
+		err := EvalCondition(tt.condition)
+		require.Equal(t, tt.met, err == nil, "test %d: %v", i, err)
+	}
+
+	// an evaluation error is not negated
+	err = EvalCondition(&Condition{Condition: "`invalid`", Expected: "1", Not: true})
+	require.Error(t, err)
+	err = EvalCondition(&Condition{Condition: "1", Expected: "re:(", Not: true})
+	require.Error(t, err)
+}
+
+func TestExpandConditions(t *testing.T) {
+	cond := []*Condition{
+		{Condition: "$A", Expected: "${B}"},
+		{Any: []*Condition{{FileExists: "/tmp/$A"}, {Command: "test $B"}}},
+	}
+	vars := map[string]string{"A": "a", "B": "b"}
+	ret := ExpandConditions(cond, func(k string) string { return vars[k] })
+	require.Equal(t, []*Condition{
+		{Condition: "a", Expected: "b"},
+		{Any: []*Condition{{FileExists: "/tmp/a"}, {Command: "test b"}}},
+	}, ret)
+	require.Equal(t, "$A", cond[0].Condition)
+}
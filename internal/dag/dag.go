@@ -14,9 +14,12 @@ import (
 
 // DAG represents a DAG configuration.
 type DAG struct {
-	Location          string
-	Group             string
-	Name              string
+	Location string
+	Group    string
+	Name     string
+	// Subdir is the subdirectory of the DAGs directory containing the DAG
+	// file. It is empty if the file is at the top of the DAGs directory.
+	Subdir            string
 	Schedule          []*Schedule
 	StopSchedule      []*Schedule
 	RestartSchedule   []*Schedule
@@ -38,11 +41,96 @@ type DAG struct {
 	DefaultParams     string
 	MaxCleanUpTime    time.Duration
 	Tags              []string
+	// Catchup is the policy to run the schedules missed while the scheduler
+	// was not running. CatchupWindow limits how far back they are run.
+	Catchup       CatchupPolicy
+	CatchupWindow time.Duration
+	// Timezone is the time zone of the schedules, e.g., America/New_York.
+	// The schedules are in the local time zone if it is empty.
+	Timezone string
+	// OverlapPolicy is the policy for a run started while the DAG is
+	// running. MaxQueueLength limits the runs queued (0 means no limit).
+	OverlapPolicy  OverlapPolicy
+	MaxQueueLength int
+	// Triggers start the DAG on the events other than the schedules.
+	Triggers []*Trigger
+}
+
+// CatchupPolicy is the policy to run the missed schedules of a DAG.
+type CatchupPolicy string
+
+const (
+	// CatchupNone does not run the missed schedules.
+	CatchupNone CatchupPolicy = "none"
+	// CatchupLatest runs the latest of the missed schedules.
+	CatchupLatest CatchupPolicy = "latest"
+	// CatchupAll runs all the missed schedules one by one.
+	CatchupAll CatchupPolicy = "all"
+)
+
+// DefaultCatchupWindow is the catch-up window of a DAG that does not
+// specify one.
+const DefaultCatchupWindow = time.Hour * 24
+
+// OverlapPolicy is the policy for a run of a DAG started while the DAG
+// is running.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip does not run the DAG.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue queues the run until the running one finishes.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapCancelPrevious stops the running one and runs the DAG.
+	OverlapCancelPrevious OverlapPolicy = "cancel-previous"
+	// OverlapAllowParallel runs the DAG along with the running one.
+	OverlapAllowParallel OverlapPolicy = "allow-parallel"
+)
+
+// RunStatus is the status of a run of another DAG to wait for.
+type RunStatus string
+
+const (
+	// RunStatusSuccess is a run that finished successfully.
+	RunStatusSuccess RunStatus = "success"
+	// RunStatusFailed is a run that failed.
+	RunStatusFailed RunStatus = "failed"
+	// RunStatusCanceled is a run that was canceled.
+	RunStatusCanceled RunStatus = "canceled"
+)
+
+// Trigger is an event other than the schedules that starts the DAG.
+type Trigger struct {
+	// DAG starts the DAG when a run of another DAG ends.
+	DAG *DAGTrigger
+	// File starts the DAG when a file arrives.
+	File *FileTrigger
+}
+
+// DAGTrigger starts the DAG when a run of another DAG ends with the status.
+type DAGTrigger struct {
+	// Name is the file of the DAG relative to the directory of the DAG file.
+	Name   string
+	Status RunStatus
+}
+
+// FileTrigger starts the DAG with the path of each file matching the
+// pattern as the parameter. A file triggers the DAG only once.
+type FileTrigger struct {
+	// Path is the glob pattern of the files relative to the directory of
+	// the DAG file.
+	Path string
+	// Stable is the time the file must be left unmodified before it
+	// triggers the DAG, so that a file still being written does not.
+	Stable time.Duration
 }
 
 type Schedule struct {
 	Expression string
 	Parsed     cron.Schedule
+	// Timezone is the time zone the schedule is in. It is empty for the
+	// local time zone.
+	Timezone string
 }
 
 type HandlerOn struct {
@@ -86,13 +174,21 @@ func (c *DAG) HasTag(tag string) bool {
 	return false
 }
 
-func (c *DAG) SockAddr() string {
+// SockDir returns the directory of the sockets of the running DAG.
+func (c *DAG) SockDir() string {
 	s := strings.ReplaceAll(c.Location, " ", "_")
 	name := strings.Replace(path.Base(s), path.Ext(path.Base(s)), "", 1)
 	h := md5.New()
 	h.Write([]byte(s))
 	bs := h.Sum(nil)
-	return path.Join("/tmp", fmt.Sprintf("@dagu-%s-%x.sock", name, bs))
+	return path.Join("/tmp", fmt.Sprintf("@dagu-%s-%x", name, bs))
+}
+
+// SockAddr returns the address of the socket of the run of the request ID.
+// The request ID is hashed to keep the address short enough for a socket.
+func (c *DAG) SockAddr(requestId string) string {
+	h := md5.Sum([]byte(requestId))
+	return path.Join(c.SockDir(), fmt.Sprintf("%x.sock", h[:8]))
 }
 
 func (c *DAG) Clone() *DAG {
@@ -5,6 +5,7 @@ type configDefinition struct {
 	Group             string
 	Description       string
 	Schedule          interface{}
+	Timezone          string
 	LogDir            string
 	Env               interface{}
 	HandlerOn         handerOnDef
@@ -21,11 +22,39 @@ type configDefinition struct {
 	Params            string
 	MaxCleanUpTimeSec *int
 	Tags              string
+	TimeoutSec        int
+	Catchup           string
+	CatchupWindowSec  int
+	Pool              string
+	OverlapPolicy     string
+	MaxQueueLength    int
+	Triggers          []*triggerDef
+}
+
+type triggerDef struct {
+	DAG  *dagTriggerDef
+	File *fileTriggerDef
+}
+
+type dagTriggerDef struct {
+	Name   string
+	Status string
+}
+
+type fileTriggerDef struct {
+	Path      string
+	StableSec int
 }
 
 type conditionDef struct {
-	Condition string
-	Expected  string
+	Condition  string
+	Expected   string
+	Not        bool
+	FileExists string
+	Command    string
+	ExitCode   int
+	All        []*conditionDef
+	Any        []*conditionDef
 }
 
 type handerOnDef struct {
@@ -52,6 +81,21 @@ type stepDef struct {
 	MailOnError   bool
 	Preconditions []*conditionDef
 	SignalOnStop  *string
+	Run           string
+	Params        string
+	Parallel      interface{}
+	TimeoutSec    int
+	When          []*conditionDef
+	Pool          string
+	WaitFor       *waitForDef
+}
+
+type waitForDef struct {
+	DAG             string
+	Status          string
+	SameDate        bool
+	PokeIntervalSec int
+	TimeoutSec      int
 }
 
 type continueOnDef struct {
@@ -65,8 +109,13 @@ type repeatPolicyDef struct {
 }
 
 type retryPolicyDef struct {
-	Limit       int
-	IntervalSec int
+	Limit          int
+	IntervalSec    int
+	Backoff        float64
+	MaxIntervalSec int
+	Jitter         float64
+	ExitCodes      []int
+	RetryOn        []int
 }
 
 type smtpConfigDef struct {
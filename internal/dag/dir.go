@@ -0,0 +1,78 @@
+package dag
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// FindFiles returns the paths of the DAG files in the directory and its
+// subdirectories relative to the directory. Hidden directories are skipped.
+func FindFiles(dir string) ([]string, error) {
+	ret := []string{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !utils.MatchExtension(d.Name(), EXTENSIONS) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		ret = append(ret, filepath.ToSlash(rel))
+		return nil
+	})
+	return ret, err
+}
+
+// ResolveFile returns the path of the DAG file given by the name, which is
+// relative to dir unless it is absolute. The extension .yaml is added when
+// the name has none.
+func ResolveFile(dir, name string) string {
+	file := os.ExpandEnv(name)
+	if filepath.Ext(file) == "" {
+		file = fmt.Sprintf("%s.yaml", file)
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return file
+}
+
+// NameOf returns the name identifying the DAG file given by the path
+// relative to the DAGs directory, i.e., the path without the extension.
+func NameOf(file string) string {
+	file = filepath.ToSlash(file)
+	return strings.TrimSuffix(file, path.Ext(file))
+}
+
+// SetDAGsDir sets the subdirectory of the DAGs directory containing the DAG
+// file. The group of a DAG in a subdirectory is the subdirectory instead of
+// the group in the definition.
+func (c *DAG) SetDAGsDir(dir string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(dir, c.Location)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	if sub := path.Dir(filepath.ToSlash(rel)); sub != "." {
+		c.Subdir = sub
+		c.Group = sub
+	}
+}
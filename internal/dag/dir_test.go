@@ -0,0 +1,52 @@
+package dag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"a.yaml", "b.txt", "team-a/etl.yaml", "team-b/etl.yml",
+		"team-b/nested/c.yaml", ".hidden/d.yaml",
+	} {
+		p := filepath.Join(dir, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("steps: []"), 0644))
+	}
+	files, err := FindFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"a.yaml", "team-a/etl.yaml", "team-b/etl.yml", "team-b/nested/c.yaml",
+	}, files)
+
+	_, err = FindFiles(filepath.Join(dir, "none"))
+	require.Error(t, err)
+}
+
+func TestNameOf(t *testing.T) {
+	require.Equal(t, "a", NameOf("a.yaml"))
+	require.Equal(t, "team-b/nested/c", NameOf("team-b/nested/c.yml"))
+}
+
+func TestSetDAGsDir(t *testing.T) {
+	for _, test := range []struct {
+		Location string
+		Subdir   string
+		Group    string
+	}{
+		{"/dags/a.yaml", "", "group"},
+		{"/dags/team-a/etl.yaml", "team-a", "team-a"},
+		{"/dags/team-b/nested/c.yaml", "team-b/nested", "team-b/nested"},
+		{"/other/a.yaml", "", "group"},
+	} {
+		d := &DAG{Location: test.Location, Group: "group"}
+		d.SetDAGsDir("/dags")
+		require.Equal(t, test.Subdir, d.Subdir, test.Location)
+		require.Equal(t, test.Group, d.Group, test.Location)
+	}
+}
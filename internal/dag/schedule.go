@@ -0,0 +1,92 @@
+package dag
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser parses the cron expressions with an optional seconds field and
+// the descriptors such as @hourly and @every 30s.
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// allHours is the bits of the hours of a cron schedule that runs every hour.
+const allHours = 1<<24 - 1
+
+func parseSchedule(values []string, timezone string) ([]*Schedule, error) {
+	ret := []*Schedule{}
+	for _, v := range values {
+		s, err := newSchedule(v, timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule: %s", err)
+		}
+		ret = append(ret, s)
+	}
+	return ret, nil
+}
+
+// newSchedule parses the cron expression in the time zone given by the
+// CRON_TZ or TZ prefix of the expression, or in the time zone of the DAG.
+func newSchedule(expr, timezone string) (*Schedule, error) {
+	spec := expr
+	if timezone != "" && !hasTimezonePrefix(expr) {
+		spec = fmt.Sprintf("CRON_TZ=%s %s", timezone, expr)
+	}
+	parsed, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schedule{Expression: expr, Parsed: parsed}
+	if ss, ok := parsed.(*cron.SpecSchedule); ok {
+		if ss.Location != time.Local {
+			s.Timezone = ss.Location.String()
+		}
+		if ss.Hour&allHours != allHours {
+			s.Parsed = newWallClockSchedule(ss)
+		}
+	}
+	return s, nil
+}
+
+func hasTimezonePrefix(expr string) bool {
+	return strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=")
+}
+
+// wallClockSchedule is a cron schedule at specific hours on the wall clock
+// of its time zone. A time skipped when DST starts runs once after the skip
+// and a time repeated when DST ends runs once.
+type wallClockSchedule struct {
+	spec *cron.SpecSchedule
+	loc  *time.Location
+}
+
+func newWallClockSchedule(s *cron.SpecSchedule) *wallClockSchedule {
+	spec := *s
+	spec.Location = time.UTC
+	return &wallClockSchedule{spec: &spec, loc: s.Location}
+}
+
+// Next returns the next time of the schedule after t. The schedule is
+// evaluated on the wall clock represented in UTC.
+func (s *wallClockSchedule) Next(t time.Time) time.Time {
+	w := wallClock(t.In(s.loc))
+	for n := s.spec.Next(w); !n.IsZero(); n = s.spec.Next(n) {
+		ret := time.Date(n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second(), 0, s.loc)
+		if d := n.Sub(wallClock(ret)); d != 0 {
+			// n is skipped when DST starts
+			ret = ret.Add(d)
+		}
+		if ret.After(t) {
+			return ret.In(t.Location())
+		}
+	}
+	return time.Time{}
+}
+
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
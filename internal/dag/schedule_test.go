@@ -0,0 +1,95 @@
+package dag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleTimezone(t *testing.T) {
+	l := &Loader{}
+	d, err := l.LoadData([]byte(`timezone: America/New_York
+schedule:
+  - "0 9 * * *"
+  - "CRON_TZ=Asia/Tokyo 0 9 * * *"
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	require.Equal(t, "America/New_York", d.Timezone)
+	require.Equal(t, "America/New_York", d.Schedule[0].Timezone)
+	require.Equal(t, "Asia/Tokyo", d.Schedule[1].Timezone)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2022, 1, 1, 14, 0, 0, 0, time.UTC), d.Schedule[0].Parsed.Next(now))
+	require.Equal(t, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), d.Schedule[1].Parsed.Next(now))
+
+	_, err = l.LoadData([]byte(`timezone: Nowhere/City
+schedule: "0 9 * * *"
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.Error(t, err)
+
+	_, err = l.LoadData([]byte(`schedule: "CRON_TZ=Nowhere/City 0 9 * * *"
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.Error(t, err)
+}
+
+func TestScheduleDST(t *testing.T) {
+	next := func(expr string, from time.Time, n int) []time.Time {
+		s, err := newSchedule(expr, "America/New_York")
+		require.NoError(t, err)
+		ret := []time.Time{}
+		for i := 0; i < n; i++ {
+			from = s.Parsed.Next(from)
+			ret = append(ret, from)
+		}
+		return ret
+	}
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	// 2:30 is skipped on 2026-03-08 and runs at 3:30 EDT
+	require.Equal(t, []time.Time{
+		utc(3, 7, 7, 30), utc(3, 8, 7, 30), utc(3, 9, 6, 30),
+	}, next("30 2 * * *", utc(3, 7, 0, 0), 3))
+
+	// 1:30 is repeated on 2026-11-01 and runs once
+	require.Equal(t, []time.Time{
+		utc(10, 31, 5, 30), utc(11, 1, 5, 30), utc(11, 2, 6, 30),
+	}, next("30 1 * * *", utc(10, 31, 0, 0), 3))
+
+	// a schedule of every hour runs every hour when DST ends
+	require.Equal(t, []time.Time{
+		utc(11, 1, 5, 0), utc(11, 1, 6, 0), utc(11, 1, 7, 0),
+	}, next("0 * * * *", utc(11, 1, 4, 30), 3))
+}
+
+func TestScheduleSeconds(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/10 * * * * *", time.Date(2022, 1, 1, 0, 0, 10, 0, time.UTC)},
+		{"30 0 9 * * *", time.Date(2022, 1, 1, 9, 0, 30, 0, time.UTC)},
+		{"@every 30s", time.Date(2022, 1, 1, 0, 0, 35, 0, time.UTC)},
+		{"@hourly", time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)},
+	} {
+		s, err := newSchedule(tc.expr, "UTC")
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.want, s.Parsed.Next(now), tc.expr)
+	}
+
+	_, err := newSchedule("@every 1x", "")
+	require.Error(t, err)
+}
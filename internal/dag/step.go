@@ -2,9 +2,15 @@ package dag
 
 import (
 	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/yohamta/dagu/internal/utils"
 )
 
 // Step represents a step in a DAG.
@@ -29,6 +35,40 @@ type Step struct {
 	MailOnError     bool
 	Preconditions   []*Condition
 	SignalOnStop    string
+	Run             string
+	Params          string
+	Parallel        *ParallelConfig
+	Timeout         time.Duration
+	When            []*Condition
+	// Pool is the name of the pool the step takes a slot of while it runs.
+	Pool string
+	// WaitFor is the run of another DAG the step waits for.
+	WaitFor *WaitFor
+}
+
+// ExecutorTypeDAG is the executor type of a step that runs another DAG.
+const ExecutorTypeDAG = "dag"
+
+// ExecutorTypeWaitFor is the executor type of a step that waits for a run
+// of another DAG.
+const ExecutorTypeWaitFor = "waitFor"
+
+// DefaultPokeInterval is the interval to check the run of the DAG a step
+// waits for when the step does not specify one.
+const DefaultPokeInterval = time.Minute
+
+// WaitFor is the run of another DAG a step waits for. The step succeeds
+// when the latest run of the DAG has the status.
+type WaitFor struct {
+	// DAG is the file of the DAG relative to the directory of the step.
+	DAG    string
+	Status RunStatus
+	// SameDate limits the runs to the ones of the same logical date as the
+	// run of the step, i.e., the date it is scheduled at or started at.
+	SameDate     bool
+	PokeInterval time.Duration
+	// Timeout is the time to wait before the step fails (0 means no limit).
+	Timeout time.Duration
 }
 
 type ExecutorConfig struct {
@@ -39,6 +79,43 @@ type ExecutorConfig struct {
 type RetryPolicy struct {
 	Limit    int
 	Interval time.Duration
+	// Backoff is the multiplier applied to the interval on each retry.
+	Backoff float64
+	// MaxInterval is the upper bound of the interval (0 means no limit).
+	MaxInterval time.Duration
+	// Jitter is the ratio of the interval randomly added or subtracted.
+	Jitter float64
+	// ExitCodes are the exit codes to retry on. Any error is retried when empty.
+	ExitCodes []int
+}
+
+// IntervalFor returns the interval before the n-th retry starting from 1.
+func (r *RetryPolicy) IntervalFor(n int) time.Duration {
+	d := float64(r.Interval)
+	if r.Backoff > 1 {
+		d *= math.Pow(r.Backoff, float64(n-1))
+	}
+	if r.MaxInterval > 0 && d > float64(r.MaxInterval) {
+		d = float64(r.MaxInterval)
+	}
+	if r.Jitter > 0 {
+		d += d * r.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+// RetryOn returns true if a step failed with the exit code should be retried.
+// The exit code is -1 when the step failed without an exit code.
+func (r *RetryPolicy) RetryOn(exitCode int) bool {
+	if len(r.ExitCodes) == 0 {
+		return true
+	}
+	for _, c := range r.ExitCodes {
+		if c == exitCode {
+			return true
+		}
+	}
+	return false
 }
 
 type RepeatPolicy struct {
@@ -46,6 +123,19 @@ type RepeatPolicy struct {
 	Interval time.Duration
 }
 
+// ParallelItemEnv is the environment variable that holds the item
+// of a step expanded from a parallel step.
+const ParallelItemEnv = "ITEM"
+
+// ParallelConfig is the configuration to run a step for each item.
+// The items are either given as Items or read from the output variable
+// named Variable when the step is ready to run.
+type ParallelConfig struct {
+	Items         []string
+	Variable      string
+	MaxConcurrent int
+}
+
 type ContinueOn struct {
 	Failure bool
 	Skipped bool
@@ -60,3 +150,37 @@ func (s *Step) String() string {
 	vals = append(vals, fmt.Sprintf("Depends: [%s]", strings.Join(s.Depends, ", ")))
 	return strings.Join(vals, "\t")
 }
+
+// LookupVariable returns the value of the output variable of a previous
+// step, or the environment variable if no step outputs it. A field of a
+// value in JSON can be referred to by a path like "RESULT.items[0].id".
+func (s *Step) LookupVariable(key string) (string, bool) {
+	name, path := utils.SplitVariablePath(key)
+	val, ok := "", false
+	if s.OutputVariables != nil {
+		if v, found := s.OutputVariables.Load(name); found {
+			val, ok = strings.TrimPrefix(v.(string), fmt.Sprintf("%s=", name)), true
+		}
+	}
+	if !ok {
+		val, ok = os.LookupEnv(name)
+	}
+	if !ok || path == "" {
+		return val, ok
+	}
+	ret, err := utils.ExtractJSONPath(val, path)
+	if err != nil {
+		log.Printf("failed to read %s: %v", key, err)
+		return "", false
+	}
+	return ret, true
+}
+
+// ExpandVariables replaces the variables in the string with the values
+// returned by LookupVariable.
+func (s *Step) ExpandVariables(str string) string {
+	return os.Expand(str, func(key string) string {
+		v, _ := s.LookupVariable(key)
+		return v
+	})
+}
@@ -0,0 +1,63 @@
+package dag
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyInterval(t *testing.T) {
+	r := &RetryPolicy{
+		Interval:    time.Second,
+		Backoff:     2,
+		MaxInterval: time.Second * 5,
+	}
+	require.Equal(t, time.Second, r.IntervalFor(1))
+	require.Equal(t, time.Second*2, r.IntervalFor(2))
+	require.Equal(t, time.Second*4, r.IntervalFor(3))
+	require.Equal(t, time.Second*5, r.IntervalFor(4))
+
+	r = &RetryPolicy{Interval: time.Second}
+	require.Equal(t, time.Second, r.IntervalFor(3))
+
+	r = &RetryPolicy{Interval: time.Second, Jitter: 0.5}
+	for i := 0; i < 10; i++ {
+		d := r.IntervalFor(1)
+		require.GreaterOrEqual(t, d, time.Millisecond*500)
+		require.LessOrEqual(t, d, time.Millisecond*1500)
+	}
+}
+
+func TestRetryPolicyRetryOn(t *testing.T) {
+	r := &RetryPolicy{}
+	require.True(t, r.RetryOn(1))
+	require.True(t, r.RetryOn(-1))
+
+	r = &RetryPolicy{ExitCodes: []int{75}}
+	require.True(t, r.RetryOn(75))
+	require.False(t, r.RetryOn(1))
+	require.False(t, r.RetryOn(-1))
+}
+
+func TestStepLookupVariable(t *testing.T) {
+	t.Setenv("STEP_TEST_ENV", `{"a":"env"}`)
+	s := &Step{OutputVariables: &sync.Map{}}
+	s.OutputVariables.Store("RESULT", `RESULT={"items":[{"id":"x1"}],"n":2}`)
+
+	v, ok := s.LookupVariable("RESULT.items[0].id")
+	require.True(t, ok)
+	require.Equal(t, "x1", v)
+
+	v, ok = s.LookupVariable("STEP_TEST_ENV.a")
+	require.True(t, ok)
+	require.Equal(t, "env", v)
+
+	_, ok = s.LookupVariable("RESULT.none")
+	require.False(t, ok)
+	_, ok = s.LookupVariable("STEP_TEST_UNKNOWN")
+	require.False(t, ok)
+
+	require.Equal(t, "x1 2 ", s.ExpandVariables("${RESULT.items[0].id} ${RESULT.n} $STEP_TEST_UNKNOWN"))
+}
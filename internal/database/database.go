@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path"
@@ -161,6 +162,7 @@ func (db *Database) RemoveOld(configPath string, retentionDays int) error {
 			info, err := os.Stat(m)
 			if err == nil {
 				if info.ModTime().Before(ot) {
+					db.removeArtifacts(configPath, m)
 					lastErr = os.Remove(m)
 				}
 			}
@@ -169,6 +171,37 @@ func (db *Database) RemoveOld(configPath string, retentionDays int) error {
 	return lastErr
 }
 
+// removeArtifacts removes the artifacts of the run saved in the status file.
+func (db *Database) removeArtifacts(configPath, file string) {
+	status, err := ParseFile(file)
+	if err != nil || status.RequestId == "" {
+		return
+	}
+	utils.LogErr("remove artifacts",
+		os.RemoveAll(db.ArtifactsDir(configPath, status.RequestId)))
+}
+
+// ArtifactsDir returns the directory to store the artifacts of a run.
+func (db *Database) ArtifactsDir(configPath, requestId string) string {
+	return filepath.Join(db.dir(configPath, prefix(configPath)), "artifacts", requestId)
+}
+
+// ListArtifacts returns the paths of the files in the artifacts directory
+// relative to it.
+func ListArtifacts(dir string) []string {
+	ret := []string{}
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			ret = append(ret, rel)
+		}
+		return nil
+	})
+	return ret
+}
+
 // Compact creates a new file with only the latest data and removes old data.
 func (db *Database) Compact(configPath, original string) error {
 	status, err := ParseFile(original)
@@ -223,6 +256,12 @@ func (db *Database) MoveData(oldPath, newPath string) error {
 		f := strings.Replace(base, oldPattern, newPattern, 1)
 		os.Rename(m, path.Join(newDir, f))
 	}
+	oldArtifacts := path.Join(oldDir, "artifacts")
+	if utils.FileExists(oldArtifacts) {
+		if err := os.Rename(oldArtifacts, path.Join(newDir, "artifacts")); err != nil {
+			return err
+		}
+	}
 	if files, _ := os.ReadDir(oldDir); len(files) == 0 {
 		os.Remove(oldDir)
 	}
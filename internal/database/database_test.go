@@ -28,6 +28,7 @@ func TestDatabase(t *testing.T) {
 		"write status and find files":         testWriteAndFindFiles,
 		"write status and find by request id": testWriteAndFindByRequestId,
 		"remove old files":                    testRemoveOldFiles,
+		"list artifacts":                      testListArtifacts,
 		"test read latest status":             testReadLatestStatus,
 		"test read latest n status":           testReadStatusN,
 		"test compaction":                     testCompactFile,
@@ -177,15 +178,30 @@ func testRemoveOldFiles(t *testing.T, db *Database) {
 	files := db.latest(db.pattern(d.Location)+"*.dat", 3)
 	require.Equal(t, 3, len(files))
 
+	artifacts := db.ArtifactsDir(d.Location, "request-id-1")
+	require.NoError(t, os.MkdirAll(artifacts, 0755))
+	require.NoError(t, os.WriteFile(path.Join(artifacts, "out.txt"), []byte("x"), 0644))
+
 	db.RemoveOld(d.Location, 0)
 
 	files = db.latest(db.pattern(d.Location)+"*.dat", 3)
 	require.Equal(t, 0, len(files))
+	require.NoDirExists(t, artifacts)
 
 	m := db.latest("invalid-pattern", 3)
 	require.Equal(t, 0, len(m))
 }
 
+func testListArtifacts(t *testing.T, db *Database) {
+	dir := db.ArtifactsDir("test_list_artifacts.yaml", "request-id-1")
+	require.NoError(t, os.MkdirAll(path.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "sub", "b.txt"), []byte("b"), 0644))
+
+	require.Equal(t, []string{"a.txt", "sub/b.txt"}, ListArtifacts(dir))
+	require.Equal(t, []string{}, ListArtifacts(path.Join(dir, "none")))
+}
+
 func testReadLatestStatus(t *testing.T, db *Database) {
 	d := &dag.DAG{
 		Location: "test_config_status_reader.yaml",
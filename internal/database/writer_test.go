@@ -48,11 +48,13 @@ func testWriteStatusToFile(t *testing.T, db *Database) {
 	newDir := db.dir(new, prefix(new))
 	require.DirExists(t, oldDir)
 	require.NoDirExists(t, newDir)
+	require.NoError(t, os.MkdirAll(db.ArtifactsDir(old, status.RequestId), 0755))
 
 	err = db.MoveData(old, new)
 	require.NoError(t, err)
 	require.NoDirExists(t, oldDir)
 	require.DirExists(t, newDir)
+	require.DirExists(t, db.ArtifactsDir(new, status.RequestId))
 
 	ret := db.ReadStatusHist(new, 1)
 	require.Equal(t, 1, len(ret))
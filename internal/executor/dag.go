@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/yohamta/dagu/internal/dag"
+)
+
+// DAGExecutable is the path to the dagu binary used to run sub-DAGs.
+var DAGExecutable = func() string {
+	p, _ := os.Executable()
+	return p
+}()
+
+// SubDAGRun is the information about a sub-DAG run passed from
+// the scheduler to the executor.
+type SubDAGRun struct {
+	// RequestId is the request ID of the new run.
+	RequestId string
+	// RetryRequestId is the request ID of the previous run to retry.
+	RetryRequestId string
+	// Parent is the location of the parent DAG.
+	Parent string
+	// ParentRequestId is the request ID of the parent run.
+	ParentRequestId string
+}
+
+type subDAGRunKey struct{}
+
+// WithSubDAGRun returns a context carrying the sub-DAG run.
+func WithSubDAGRun(ctx context.Context, run *SubDAGRun) context.Context {
+	return context.WithValue(ctx, subDAGRunKey{}, run)
+}
+
+// SubDAGRunFromContext returns the sub-DAG run in the context if any.
+func SubDAGRunFromContext(ctx context.Context) *SubDAGRun {
+	run, _ := ctx.Value(subDAGRunKey{}).(*SubDAGRun)
+	return run
+}
+
+// DAGExecutor runs another DAG as a child process and waits for it.
+type DAGExecutor struct {
+	cmd *exec.Cmd
+}
+
+func (e *DAGExecutor) Run() error {
+	return e.cmd.Run()
+}
+
+func (e *DAGExecutor) SetStdout(out io.Writer) {
+	e.cmd.Stdout = out
+}
+
+func (e *DAGExecutor) SetStderr(out io.Writer) {
+	e.cmd.Stderr = out
+}
+
+func (e *DAGExecutor) Kill(sig os.Signal) error {
+	if e.cmd == nil || e.cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-e.cmd.Process.Pid, sig.(syscall.Signal))
+}
+
+func CreateDAGExecutor(ctx context.Context, step *dag.Step) (Executor, error) {
+	if step.Run == "" {
+		return nil, fmt.Errorf("DAG to run must be specified")
+	}
+	run := SubDAGRunFromContext(ctx)
+	if run == nil {
+		return nil, fmt.Errorf("sub-DAG run is not set up")
+	}
+
+	file := dag.ResolveFile(step.Dir, step.Run)
+
+	args := []string{}
+	if run.RetryRequestId != "" {
+		args = append(args, "retry",
+			fmt.Sprintf("--req=%s", run.RetryRequestId),
+			fmt.Sprintf("--new-req=%s", run.RequestId),
+		)
+	} else {
+		args = append(args, "start", fmt.Sprintf("--req=%s", run.RequestId))
+		if step.Params != "" {
+			args = append(args, fmt.Sprintf("--params=%s", step.ExpandVariables(step.Params)))
+		}
+	}
+	args = append(args,
+		fmt.Sprintf("--parent=%s", run.Parent),
+		fmt.Sprintf("--parent-req=%s", run.ParentRequestId),
+		file,
+	)
+
+	cmd := exec.CommandContext(ctx, DAGExecutable, args...)
+	cmd.Dir = step.Dir
+	cmd.Env = append(os.Environ(), step.Variables...)
+	step.OutputVariables.Range(func(key, value interface{}) bool {
+		cmd.Env = append(cmd.Env, value.(string))
+		return true
+	})
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+		Pgid:    0,
+	}
+	// let the child DAG stop its own steps instead of killing it outright
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+
+	return &DAGExecutor{
+		cmd: cmd,
+	}, nil
+}
+
+func init() {
+	Register(dag.ExecutorTypeDAG, CreateDAGExecutor)
+}
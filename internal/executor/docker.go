@@ -1,38 +1,148 @@
 package executor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"syscall"
 
+	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/mitchellh/mapstructure"
 	"github.com/yohamta/dagu/internal/dag"
 )
 
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// DockerExecutor runs a step in a new docker container. It talks to
+// the Docker Engine API over the unix socket or the tcp address given
+// by DOCKER_HOST.
 type DockerExecutor struct {
 	config *container.Config
+	client *dockerClient
+	ctx    context.Context
 	stdout io.Writer
+	stderr io.Writer
+
+	mu          sync.Mutex
+	containerId string
+	// killed is set when the executor is killed before the container
+	// has started. Run removes the container instead of starting it.
+	killed bool
+	cancel context.CancelFunc
 }
 
+var errDockerKilled = errors.New("killed before the container started")
+
 func (e *DockerExecutor) SetStdout(out io.Writer) {
 	e.stdout = out
 }
 
 func (e *DockerExecutor) SetStderr(out io.Writer) {
-	e.stdout = out
+	e.stderr = out
 }
 
 func (e *DockerExecutor) Kill(sig os.Signal) error {
-	// TODO: stop container
-	return nil
+	e.mu.Lock()
+	id := e.containerId
+	if id == "" {
+		// the image may be being pulled, so the requests are canceled
+		e.killed = true
+		e.cancel()
+	}
+	e.mu.Unlock()
+	if id == "" {
+		return nil
+	}
+	return e.client.kill(id, sig)
 }
 
 func (e *DockerExecutor) Run() error {
-	// TODO: start container
+	defer e.cancel()
+	id, err := e.client.create(e.ctx, e.config)
+	if err != nil {
+		if e.isKilled() {
+			return errDockerKilled
+		}
+		return err
+	}
+
+	defer func() {
+		// the step context may already be canceled at this point
+		_ = e.client.remove(context.Background(), id)
+	}()
+
+	if e.isKilled() {
+		return errDockerKilled
+	}
+	err = e.client.start(e.ctx, id)
+	e.mu.Lock()
+	killed := e.killed
+	if !killed {
+		e.containerId = id
+	}
+	e.mu.Unlock()
+	if killed {
+		// the container is removed by force even if it has started
+		return errDockerKilled
+	}
+	if err != nil {
+		return err
+	}
+
+	logs, err := e.client.logs(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	if e.config.Tty {
+		_, err = io.Copy(e.stdout, logs)
+	} else {
+		_, err = stdcopy.StdCopy(e.stdout, e.stderr, logs)
+	}
+	logs.Close()
+	if err != nil {
+		return err
+	}
+
+	code, err := e.client.wait(e.ctx, id)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &dockerExitError{code: int(code)}
+	}
 	return nil
 }
 
+func (e *DockerExecutor) isKilled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.killed
+}
+
+// dockerExitError is the error of a container exited with non-zero code.
+type dockerExitError struct {
+	code int
+}
+
+func (e *dockerExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *dockerExitError) ExitCode() int {
+	return e.code
+}
+
 func CreateDockerExecutor(ctx context.Context, step *dag.Step) (Executor, error) {
 	cfg := &container.Config{}
 	md, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
@@ -47,12 +157,213 @@ func CreateDockerExecutor(ctx context.Context, step *dag.Step) (Executor, error)
 		return nil, err
 	}
 
+	if cfg.Image == "" {
+		return nil, fmt.Errorf("docker image must be specified")
+	}
+
+	if step.Command != "" {
+		cfg.Cmd = append([]string{step.Command}, step.Args...)
+	}
+
+	client, err := newDockerClient(dockerHost(step.Variables))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
 	return &DockerExecutor{
+		ctx:    ctx,
+		cancel: cancel,
+		client: client,
 		stdout: os.Stdout,
+		stderr: os.Stderr,
 		config: cfg,
 	}, nil
 }
 
+// dockerHost returns DOCKER_HOST from the step environment,
+// the process environment, or the default socket in this order.
+func dockerHost(variables []string) string {
+	for _, v := range variables {
+		if strings.HasPrefix(v, "DOCKER_HOST=") {
+			return strings.TrimPrefix(v, "DOCKER_HOST=")
+		}
+	}
+	if h := os.Getenv("DOCKER_HOST"); h != "" {
+		return h
+	}
+	return defaultDockerHost
+}
+
+type dockerClient struct {
+	http *http.Client
+}
+
+// newDockerClient returns a client of the docker host such as
+// "unix:///var/run/docker.sock" or "tcp://localhost:2375". TLS is
+// not supported.
+func newDockerClient(host string) (*dockerClient, error) {
+	network, addr, ok := strings.Cut(host, "://")
+	if !ok || addr == "" || (network != "unix" && network != "tcp") {
+		return nil, fmt.Errorf("unsupported docker host: %s", host)
+	}
+	return &dockerClient{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, addr)
+				},
+			},
+		},
+	}, nil
+}
+
+func (c *dockerClient) create(ctx context.Context, cfg *container.Config) (string, error) {
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	res, err := c.do(ctx, http.MethodPost, "/containers/create", nil, body)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		// the image is not available locally
+		res.Body.Close()
+		if err := c.pull(ctx, cfg.Image); err != nil {
+			return "", err
+		}
+		res, err = c.do(ctx, http.MethodPost, "/containers/create", nil, body)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+	}
+	if err := checkResponse("create container", res); err != nil {
+		return "", err
+	}
+	ret := &container.ContainerCreateCreatedBody{}
+	if err := json.NewDecoder(res.Body).Decode(ret); err != nil {
+		return "", err
+	}
+	return ret.ID, nil
+}
+
+func (c *dockerClient) pull(ctx context.Context, image string) error {
+	q := url.Values{}
+	q.Set("fromImage", image)
+	if !strings.Contains(image, ":") {
+		q.Set("tag", "latest")
+	}
+	res, err := c.do(ctx, http.MethodPost, "/images/create", q, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse("pull image", res); err != nil {
+		return err
+	}
+	// the progress stream must be drained to complete the pull
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
+
+func (c *dockerClient) start(ctx context.Context, id string) error {
+	return c.call(ctx, "start container", http.MethodPost,
+		fmt.Sprintf("/containers/%s/start", id), nil)
+}
+
+func (c *dockerClient) logs(ctx context.Context, id string) (io.ReadCloser, error) {
+	q := url.Values{}
+	q.Set("follow", "1")
+	q.Set("stdout", "1")
+	q.Set("stderr", "1")
+	res, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/containers/%s/logs", id), q, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse("read container logs", res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (c *dockerClient) wait(ctx context.Context, id string) (int64, error) {
+	res, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/wait", id), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse("wait container", res); err != nil {
+		return 0, err
+	}
+	ret := &container.ContainerWaitOKBody{}
+	if err := json.NewDecoder(res.Body).Decode(ret); err != nil {
+		return 0, err
+	}
+	if ret.Error != nil && ret.Error.Message != "" {
+		return 0, fmt.Errorf("wait container failed: %s", ret.Error.Message)
+	}
+	return ret.StatusCode, nil
+}
+
+func (c *dockerClient) kill(id string, sig os.Signal) error {
+	q := url.Values{}
+	if s, ok := sig.(syscall.Signal); ok {
+		q.Set("signal", fmt.Sprintf("%d", int(s)))
+	}
+	return c.call(context.Background(), "kill container", http.MethodPost,
+		fmt.Sprintf("/containers/%s/kill", id), q)
+}
+
+func (c *dockerClient) remove(ctx context.Context, id string) error {
+	q := url.Values{}
+	q.Set("force", "1")
+	return c.call(ctx, "remove container", http.MethodDelete,
+		fmt.Sprintf("/containers/%s", id), q)
+}
+
+func (c *dockerClient) call(ctx context.Context, action, method, path string, query url.Values) error {
+	res, err := c.do(ctx, method, path, query, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return checkResponse(action, res)
+}
+
+func (c *dockerClient) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	u := fmt.Sprintf("http://docker/v%s%s", api.DefaultVersion, path)
+	if len(query) > 0 {
+		u = fmt.Sprintf("%s?%s", u, query.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.http.Do(req)
+}
+
+func checkResponse(action string, res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	msg := struct {
+		Message string `json:"message"`
+	}{}
+	b, _ := io.ReadAll(res.Body)
+	if err := json.Unmarshal(b, &msg); err != nil || msg.Message == "" {
+		msg.Message = strings.TrimSpace(string(b))
+	}
+	return fmt.Errorf("%s failed: %s (status %d)", action, msg.Message, res.StatusCode)
+}
+
 func init() {
 	Register("docker", CreateDockerExecutor)
 }
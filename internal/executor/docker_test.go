@@ -0,0 +1,240 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/dag"
+)
+
+type fakeDocker struct {
+	mu       sync.Mutex
+	created  *container.Config
+	killed   string
+	removed  bool
+	exitCode int64
+	block    chan struct{}
+	// creating blocks the creation of the container until the request
+	// is canceled, as pulling the image does.
+	creating bool
+	started  bool
+}
+
+func (d *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Path[strings.Index(r.URL.Path, "/containers"):]
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && p == "/containers/create" && d.creating:
+		d.created = &container.Config{}
+		d.mu.Unlock()
+		<-r.Context().Done()
+		d.mu.Lock()
+	case r.Method == http.MethodPost && p == "/containers/create":
+		d.created = &container.Config{}
+		_ = json.NewDecoder(r.Body).Decode(d.created)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&container.ContainerCreateCreatedBody{ID: "test"})
+	case r.Method == http.MethodPost && p == "/containers/test/start":
+		d.started = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && p == "/containers/test/logs":
+		w.WriteHeader(http.StatusOK)
+		_, _ = stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte("hello stdout\n"))
+		_, _ = stdcopy.NewStdWriter(w, stdcopy.Stderr).Write([]byte("hello stderr\n"))
+		if block := d.block; block != nil {
+			w.(http.Flusher).Flush()
+			d.mu.Unlock()
+			<-block
+			d.mu.Lock()
+		}
+	case r.Method == http.MethodPost && p == "/containers/test/wait":
+		_ = json.NewEncoder(w).Encode(&container.ContainerWaitOKBody{StatusCode: d.exitCode})
+	case r.Method == http.MethodPost && p == "/containers/test/kill":
+		d.killed = r.URL.Query().Get("signal")
+		if d.block != nil {
+			close(d.block)
+			d.block = nil
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && p == "/containers/test":
+		d.removed = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func startFakeDocker(t *testing.T, d *fakeDocker) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "docker_executor_test")
+	require.NoError(t, err)
+	sock := path.Join(dir, "docker.sock")
+	ln, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	serveFakeDocker(t, d, ln)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	return sock
+}
+
+func serveFakeDocker(t *testing.T, d *fakeDocker, ln net.Listener) {
+	t.Helper()
+	svr := &http.Server{Handler: d}
+	go func() {
+		_ = svr.Serve(ln)
+	}()
+	t.Cleanup(func() {
+		_ = svr.Close()
+	})
+}
+
+func dockerStep(sock string) *dag.Step {
+	return &dag.Step{
+		Command:   "echo",
+		Args:      []string{"hello"},
+		Variables: []string{"DOCKER_HOST=unix://" + sock},
+		ExecutorConfig: dag.ExecutorConfig{
+			Type: "docker",
+			Config: map[string]interface{}{
+				"image": "alpine:latest",
+			},
+		},
+	}
+}
+
+func TestDockerExecutor(t *testing.T) {
+	d := &fakeDocker{}
+	sock := startFakeDocker(t, d)
+
+	e, err := CreateDockerExecutor(context.Background(), dockerStep(sock))
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	e.SetStdout(&stdout)
+	e.SetStderr(&stderr)
+
+	require.NoError(t, e.Run())
+	require.Equal(t, "hello stdout\n", stdout.String())
+	require.Equal(t, "hello stderr\n", stderr.String())
+	require.Equal(t, "alpine:latest", d.created.Image)
+	require.Equal(t, []string{"echo", "hello"}, []string(d.created.Cmd))
+	require.True(t, d.removed)
+}
+
+func TestDockerExecutorTCP(t *testing.T) {
+	d := &fakeDocker{}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveFakeDocker(t, d, ln)
+
+	step := dockerStep("")
+	step.Variables = []string{"DOCKER_HOST=tcp://" + ln.Addr().String()}
+	e, err := CreateDockerExecutor(context.Background(), step)
+	require.NoError(t, err)
+
+	var stdout bytes.Buffer
+	e.SetStdout(&stdout)
+	e.SetStderr(&bytes.Buffer{})
+
+	require.NoError(t, e.Run())
+	require.Equal(t, "hello stdout\n", stdout.String())
+	require.True(t, d.removed)
+}
+
+func TestDockerExecutorExitCode(t *testing.T) {
+	d := &fakeDocker{exitCode: 2}
+	sock := startFakeDocker(t, d)
+
+	e, err := CreateDockerExecutor(context.Background(), dockerStep(sock))
+	require.NoError(t, err)
+	e.SetStdout(&bytes.Buffer{})
+	e.SetStderr(&bytes.Buffer{})
+
+	err = e.Run()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exit status 2")
+	require.True(t, d.removed)
+}
+
+func TestDockerExecutorKill(t *testing.T) {
+	d := &fakeDocker{block: make(chan struct{})}
+	sock := startFakeDocker(t, d)
+
+	e, err := CreateDockerExecutor(context.Background(), dockerStep(sock))
+	require.NoError(t, err)
+	e.SetStdout(&bytes.Buffer{})
+	e.SetStderr(&bytes.Buffer{})
+
+	done := make(chan error)
+	go func() {
+		done <- e.Run()
+	}()
+
+	require.Eventually(t, func() bool {
+		return e.(*DockerExecutor).Kill(syscall.SIGINT) == nil &&
+			d.killedSignal() != ""
+	}, time.Second, time.Millisecond*50)
+	require.Equal(t, "2", d.killedSignal())
+
+	require.NoError(t, <-done)
+}
+
+func TestDockerExecutorKillBeforeStart(t *testing.T) {
+	d := &fakeDocker{creating: true}
+	sock := startFakeDocker(t, d)
+
+	e, err := CreateDockerExecutor(context.Background(), dockerStep(sock))
+	require.NoError(t, err)
+	e.SetStdout(&bytes.Buffer{})
+	e.SetStderr(&bytes.Buffer{})
+
+	done := make(chan error)
+	go func() {
+		done <- e.Run()
+	}()
+
+	require.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.created != nil
+	}, time.Second, time.Millisecond*50)
+	require.NoError(t, e.Kill(syscall.SIGINT))
+
+	require.ErrorIs(t, <-done, errDockerKilled)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	require.False(t, d.started)
+}
+
+func (d *fakeDocker) killedSignal() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.killed
+}
+
+func TestCreateDockerExecutorErrors(t *testing.T) {
+	step := dockerStep("")
+	step.ExecutorConfig.Config = map[string]interface{}{}
+	_, err := CreateDockerExecutor(context.Background(), step)
+	require.Error(t, err)
+
+	step = dockerStep("")
+	step.Variables = []string{"DOCKER_HOST=ssh://user@localhost"}
+	_, err = CreateDockerExecutor(context.Background(), step)
+	require.Error(t, err)
+}
@@ -18,6 +18,12 @@ type Node struct {
 	DoneCount  int                  `json:"DoneCount"`
 	Error      string               `json:"Error"`
 	StatusText string               `json:"StatusText"`
+	// ChildRequestId is the request ID of the sub-DAG run.
+	ChildRequestId string `json:"ChildRequestId"`
+	// ParallelParent is the name of the parallel step the node is expanded from.
+	ParallelParent string `json:"ParallelParent"`
+	// SkipReason is the reason the node is skipped as a branch not taken.
+	SkipReason string `json:"SkipReason"`
 }
 
 func (n *Node) ToNode() *scheduler.Node {
@@ -37,6 +43,10 @@ func (n *Node) ToNode() *scheduler.Node {
 			RetryCount: n.RetryCount,
 			DoneCount:  n.DoneCount,
 			Error:      err,
+
+			ChildRequestId: n.ChildRequestId,
+			ParallelParent: n.ParallelParent,
+			SkipReason:     n.SkipReason,
 		},
 	}
 	return ret
@@ -52,6 +62,10 @@ func FromNode(n *scheduler.Node) *Node {
 		StatusText: n.ReadStatus().String(),
 		RetryCount: n.ReadRetryCount(),
 		DoneCount:  n.ReadDoneCount(),
+
+		ChildRequestId: n.ReadChildRequestId(),
+		ParallelParent: n.ParallelParent,
+		SkipReason:     n.ReadSkipReason(),
 	}
 	if n.Error != nil {
 		node.Error = n.Error.Error()
@@ -45,6 +45,20 @@ type Status struct {
 	FinishedAt string                    `json:"FinishedAt"`
 	Log        string                    `json:"Log"`
 	Params     string                    `json:"Params"`
+	// Parent is the location of the parent DAG when run as a sub-DAG.
+	Parent          string `json:"Parent"`
+	ParentRequestId string `json:"ParentRequestId"`
+	// Outputs are the values of the output variables passed between steps.
+	Outputs map[string]string `json:"Outputs"`
+	// Artifacts are the files stored in the artifacts directory of the run.
+	Artifacts []string `json:"Artifacts"`
+	// Revision is the hash of the DAG definition the run executed.
+	Revision string `json:"Revision"`
+	// ScheduledTime is the time the run is scheduled at when it is started
+	// by the scheduler.
+	ScheduledTime string `json:"ScheduledTime,omitempty"`
+	// BackfillId is the ID of the backfill the run belongs to.
+	BackfillId string `json:"BackfillId,omitempty"`
 }
 
 type StatusFile struct {
@@ -0,0 +1,72 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/utils"
+	"golang.org/x/sys/unix"
+)
+
+// Pool is a named set of slots shared by the steps of all DAGs. A step
+// in a pool runs only while it holds one of the slots.
+//
+// A slot is held by locking a file in the directory of the pool, so the
+// agents running in separate processes share the slots, and a slot is
+// released by the OS when the process holding it exits.
+type Pool struct {
+	Name  string
+	Slots int
+	// Dir is the directory of the lock files of the pools.
+	Dir string
+}
+
+// Slot is a slot of a pool held by a step.
+type Slot struct {
+	file *os.File
+}
+
+// New returns a pool with the lock files in the default directory.
+func New(name string, slots int) *Pool {
+	return &Pool{
+		Name:  name,
+		Slots: slots,
+		Dir:   settings.MustGet(settings.SETTING__POOLS_DIR),
+	}
+}
+
+// TryAcquire takes a free slot of the pool. It returns nil when all of the
+// slots are in use.
+func (p *Pool) TryAcquire() (*Slot, error) {
+	dir := path.Join(p.Dir, utils.ValidFilename(p.Name, "_"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	for i := 0; i < p.Slots; i++ {
+		f, err := os.OpenFile(path.Join(dir, fmt.Sprintf("%d.lock", i)), os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			return &Slot{file: f}, nil
+		}
+		_ = f.Close()
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Release frees the slot.
+func (s *Slot) Release() error {
+	if err := unix.Flock(int(s.file.Fd()), unix.LOCK_UN); err != nil {
+		_ = s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
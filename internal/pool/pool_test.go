@@ -0,0 +1,39 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryAcquire(t *testing.T) {
+	p := &Pool{Name: "test", Slots: 2, Dir: t.TempDir()}
+
+	s1, err := p.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, s1)
+
+	s2, err := p.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, s2)
+
+	// all of the slots are in use
+	s3, err := p.TryAcquire()
+	require.NoError(t, err)
+	require.Nil(t, s3)
+
+	require.NoError(t, s1.Release())
+
+	s3, err = p.TryAcquire()
+	require.NoError(t, err)
+	require.NotNil(t, s3)
+
+	// the pools with the same name share the slots
+	other := &Pool{Name: "test", Slots: 2, Dir: p.Dir}
+	s4, err := other.TryAcquire()
+	require.NoError(t, err)
+	require.Nil(t, s4)
+
+	require.NoError(t, s2.Release())
+	require.NoError(t, s3.Release())
+}
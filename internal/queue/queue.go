@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yohamta/dagu/internal/settings"
+)
+
+// Item is a run of a DAG waiting in the queue.
+type Item struct {
+	RequestId string `json:"RequestId"`
+	Params    string `json:"Params"`
+	// ScheduledTime is the time the run is scheduled at in RFC3339, or
+	// empty if it is not started by the scheduler.
+	ScheduledTime string `json:"ScheduledTime,omitempty"`
+	// BackfillId is the ID of the backfill when the run is a part of it.
+	BackfillId string    `json:"BackfillId,omitempty"`
+	QueuedAt   time.Time `json:"QueuedAt"`
+}
+
+var ErrQueueFull = errors.New("the queue is full")
+
+// Queue stores the runs of the DAGs started while the DAGs are running.
+// The runs of each DAG are stored as files in its own directory, named
+// by the time they are queued so that they are taken in order.
+type Queue struct {
+	Dir string
+}
+
+// New returns the queue in the default directory.
+func New() *Queue {
+	return &Queue{
+		Dir: settings.MustGet(settings.SETTING__QUEUE_DIR),
+	}
+}
+
+// Push adds the run to the end of the queue of the DAG and returns its
+// position starting from 1. It returns ErrQueueFull when the queue already
+// has max runs, unless max is 0.
+func (q *Queue) Push(location string, item *Item, max int) (int, error) {
+	files, err := q.files(location)
+	if err != nil {
+		return 0, err
+	}
+	if max > 0 && len(files) >= max {
+		return 0, ErrQueueFull
+	}
+	dir := q.dir(location)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	item.QueuedAt = time.Now()
+	b, err := json.Marshal(item)
+	if err != nil {
+		return 0, err
+	}
+	name := fmt.Sprintf("%020d.%s.json", item.QueuedAt.UnixNano(), item.RequestId)
+	if err := os.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+		return 0, err
+	}
+	return len(files) + 1, nil
+}
+
+// List returns the runs in the queue of the DAG in order.
+func (q *Queue) List(location string) ([]*Item, error) {
+	files, err := q.files(location)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Item, 0, len(files))
+	for _, f := range files {
+		item, err := readItem(f)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+// Pop removes the first run from the queue of the DAG and returns it, or
+// nil if the queue is empty.
+func (q *Queue) Pop(location string) (*Item, error) {
+	files, err := q.files(location)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	item, err := readItem(files[0])
+	if err != nil {
+		return nil, err
+	}
+	return item, os.Remove(files[0])
+}
+
+// Remove removes the run of the request ID from the queue of the DAG.
+func (q *Queue) Remove(location, requestId string) error {
+	files, err := q.files(location)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, "."+requestId+".json") {
+			return os.Remove(f)
+		}
+	}
+	return fmt.Errorf("request ID %s is not in the queue", requestId)
+}
+
+func (q *Queue) files(location string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(q.dir(location), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (q *Queue) dir(location string) string {
+	h := md5.Sum([]byte(location))
+	name := strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+	return filepath.Join(q.Dir, fmt.Sprintf("%s-%s", name, hex.EncodeToString(h[:])))
+}
+
+func readItem(file string) (*Item, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	item := &Item{}
+	if err := json.Unmarshal(b, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	q := &Queue{Dir: t.TempDir()}
+	loc := "/dags/test.yaml"
+
+	item, err := q.Pop(loc)
+	require.NoError(t, err)
+	require.Nil(t, item)
+
+	for i, id := range []string{"a", "b", "c"} {
+		pos, err := q.Push(loc, &Item{RequestId: id, Params: "p-" + id}, 3)
+		require.NoError(t, err)
+		require.Equal(t, i+1, pos)
+	}
+	_, err = q.Push(loc, &Item{RequestId: "d"}, 3)
+	require.ErrorIs(t, err, ErrQueueFull)
+
+	// the queues of the DAGs are separate
+	pos, err := q.Push("/dags/other.yaml", &Item{RequestId: "e"}, 3)
+	require.NoError(t, err)
+	require.Equal(t, 1, pos)
+
+	require.NoError(t, q.Remove(loc, "b"))
+	require.Error(t, q.Remove(loc, "b"))
+
+	items, err := q.List(loc)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "a", items[0].RequestId)
+	require.Equal(t, "p-a", items[0].Params)
+	require.Equal(t, "c", items[1].RequestId)
+
+	item, err = q.Pop(loc)
+	require.NoError(t, err)
+	require.Equal(t, "a", item.RequestId)
+
+	items, err = q.List(loc)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "c", items[0].RequestId)
+}
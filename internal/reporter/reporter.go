@@ -34,7 +34,7 @@ func (rp *Reporter) ReportStep(d *dag.DAG, status *models.Status, node *schedule
 	if st != scheduler.NodeStatus_None {
 		log.Printf("%s %s", node.Name, status.StatusText)
 	}
-	if st == scheduler.NodeStatus_Error && node.MailOnError {
+	if (st == scheduler.NodeStatus_Error || st == scheduler.NodeStatus_Timeout) && node.MailOnError {
 		return rp.Mailer.SendMail(
 			d.ErrorMail.From,
 			[]string{d.ErrorMail.To},
@@ -144,7 +144,7 @@ func renderHTML(nodes []*models.Node) string {
 	addStatusFunc := func(status scheduler.NodeStatus) {
 		style := ""
 		switch status {
-		case scheduler.NodeStatus_Error:
+		case scheduler.NodeStatus_Error, scheduler.NodeStatus_Timeout:
 			style = "color: #D01117;font-weight:bold;"
 		}
 		buffer.WriteString(
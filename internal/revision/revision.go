@@ -0,0 +1,150 @@
+package revision
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yohamta/dagu/internal/settings"
+)
+
+// Revision is a saved version of a DAG definition.
+type Revision struct {
+	// Hash is the SHA-256 hash of the definition.
+	Hash      string    `json:"Hash"`
+	Author    string    `json:"Author"`
+	Message   string    `json:"Message"`
+	Timestamp time.Time `json:"Timestamp"`
+}
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Hash returns the hash of the DAG definition.
+func Hash(spec []byte) string {
+	h := sha256.Sum256(spec)
+	return hex.EncodeToString(h[:])
+}
+
+// HashFile returns the hash of the DAG file or an empty string if it
+// cannot be read.
+func HashFile(file string) string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	return Hash(b)
+}
+
+// Store stores the revisions of the DAG definitions. The revisions of
+// each DAG are stored in its own directory, which has the definitions
+// named by their hashes and the list of the revisions.
+type Store struct {
+	Dir string
+}
+
+// New returns the store in the default directory.
+func New() *Store {
+	return &Store{
+		Dir: settings.MustGet(settings.SETTING__REVISIONS_DIR),
+	}
+}
+
+const indexFile = "revisions.jsonl"
+
+// Save stores the definition as a new revision of the DAG.
+func (s *Store) Save(location string, spec []byte, author, message string) (*Revision, error) {
+	dir := s.dir(location)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	rev := &Revision{
+		Hash:      Hash(spec),
+		Author:    author,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+	if err := os.WriteFile(path.Join(dir, rev.Hash+".yaml"), spec, 0644); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(rev)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path.Join(dir, indexFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// List returns the revisions of the DAG, newest first.
+func (s *Store) List(location string) ([]*Revision, error) {
+	ret := []*Revision{}
+	f, err := os.Open(path.Join(s.dir(location), indexFile))
+	if os.IsNotExist(err) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		rev := &Revision{}
+		if err := json.Unmarshal(sc.Bytes(), rev); err == nil {
+			ret = append([]*Revision{rev}, ret...)
+		}
+	}
+	return ret, sc.Err()
+}
+
+// Get returns the latest revision of the hash and its definition.
+func (s *Store) Get(location, hash string) (*Revision, string, error) {
+	revs, err := s.List(location)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, rev := range revs {
+		if rev.Hash == hash {
+			b, err := os.ReadFile(path.Join(s.dir(location), hash+".yaml"))
+			if err != nil {
+				return nil, "", err
+			}
+			return rev, string(b), nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrRevisionNotFound, hash)
+}
+
+// Move moves the revisions of the DAG to the new location.
+func (s *Store) Move(oldLocation, newLocation string) error {
+	oldDir := s.dir(oldLocation)
+	if _, err := os.Stat(oldDir); os.IsNotExist(err) {
+		return nil
+	}
+	return os.Rename(oldDir, s.dir(newLocation))
+}
+
+// Remove removes the revisions of the DAG.
+func (s *Store) Remove(location string) error {
+	return os.RemoveAll(s.dir(location))
+}
+
+func (s *Store) dir(location string) string {
+	h := md5.Sum([]byte(location))
+	name := strings.TrimSuffix(filepath.Base(location), filepath.Ext(location))
+	return filepath.Join(s.Dir, fmt.Sprintf("%s-%s", name, hex.EncodeToString(h[:])))
+}
@@ -0,0 +1,60 @@
+package revision
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	s := &Store{Dir: t.TempDir()}
+	loc := "/dags/test.yaml"
+
+	revs, err := s.List(loc)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(revs))
+
+	r1, err := s.Save(loc, []byte("a"), "alice", "first")
+	require.NoError(t, err)
+	require.Equal(t, Hash([]byte("a")), r1.Hash)
+	r2, err := s.Save(loc, []byte("b"), "bob", "second")
+	require.NoError(t, err)
+
+	revs, err = s.List(loc)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revs))
+	require.Equal(t, r2.Hash, revs[0].Hash)
+	require.Equal(t, "second", revs[0].Message)
+	require.Equal(t, r1.Hash, revs[1].Hash)
+
+	rev, spec, err := s.Get(loc, r1.Hash)
+	require.NoError(t, err)
+	require.Equal(t, "alice", rev.Author)
+	require.Equal(t, "a", spec)
+
+	_, _, err = s.Get(loc, "invalid")
+	require.ErrorIs(t, err, ErrRevisionNotFound)
+
+	// revisions of other DAGs are not mixed
+	revs, err = s.List("/dags/other/test.yaml")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(revs))
+
+	newLoc := "/dags/renamed.yaml"
+	require.NoError(t, s.Move(loc, newLoc))
+	revs, err = s.List(newLoc)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revs))
+	revs, err = s.List(loc)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(revs))
+
+	require.NoError(t, s.Remove(newLoc))
+	revs, err = s.List(newLoc)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(revs))
+}
+
+func TestHashFile(t *testing.T) {
+	require.Equal(t, "", HashFile("/not/exist.yaml"))
+}
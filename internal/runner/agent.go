@@ -51,7 +51,12 @@ func (a *Agent) start() error {
 
 	log.Printf("starting dagu scheduler")
 	a.stop = make(chan struct{})
-	runner := New(newEntryReader(a.Config))
+	er := newEntryReader(a.Config)
+	er.catchup()
+	go er.drainQueues()
+	go er.watchTriggers()
+	go er.watchFiles()
+	runner := New(er)
 	a.registerRunnerShutdown(runner)
 
 	go runner.Start()
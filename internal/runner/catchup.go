@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// catchupInterval is the interval to wait for a DAG that is already running
+// before running its missed schedule.
+var catchupInterval = time.Second * 10
+
+// catchup runs the schedules of the DAGs missed while the scheduler was not
+// running according to their catchup policies. The schedules of a DAG are
+// not started by the runner until the DAG has caught up.
+func (er *entryReader) catchup() {
+	er.dagsLock.Lock()
+	defer er.dagsLock.Unlock()
+	for file, d := range er.dags {
+		if d.Catchup == dag.CatchupNone || len(d.Schedule) == 0 ||
+			er.suspendChecker.IsSuspended(d) {
+			continue
+		}
+		er.catchingUp[file] = true
+		go er.catchupDAG(file, d)
+	}
+}
+
+// catchupDAG runs the missed schedules of the DAG one by one, including the
+// ones missed while catching up, since the last run recorded in the database.
+// Nothing is run for a DAG that has never run.
+func (er *entryReader) catchupDAG(file string, d *dag.DAG) {
+	defer func() {
+		er.dagsLock.Lock()
+		delete(er.catchingUp, file)
+		er.dagsLock.Unlock()
+	}()
+	// the last run may be on an earlier day than today
+	runs := controller.NewDAGController(d).GetRecentStatuses(1)
+	if len(runs) == 0 {
+		return
+	}
+	last, err := lastRunTime(runs[0].Status)
+	if err != nil {
+		log.Printf("catchup: failed to read the last run of %s: %v", d.Name, err)
+		return
+	}
+	if last.IsZero() {
+		return
+	}
+	for !er.suspendChecker.IsSuspended(d) {
+		next := missedSchedule(d, last, utils.Now())
+		if next.IsZero() {
+			return
+		}
+		j := &job{DAG: d, Config: er.Admin, Next: next}
+		log.Printf("[%s] catch up %s", next.Format("2006-01-02 15:04:05"), j)
+		err := j.Start()
+		if errors.Is(err, ErrJobRunning) {
+			time.Sleep(catchupInterval)
+			continue
+		}
+		if err != nil {
+			log.Printf("catchup: %s failed: %v", j, err)
+		}
+		last = next
+	}
+}
+
+// missedSchedule returns the time of the missed schedule of the DAG to run
+// next, which is the earliest one for CatchupAll and the latest one for
+// CatchupLatest, or the zero time if no schedule has been missed. Only the
+// schedules after the last run and in the catch-up window are considered.
+func missedSchedule(d *dag.DAG, last, now time.Time) time.Time {
+	from := last
+	if w := now.Add(-d.CatchupWindow); from.Before(w) {
+		from = w
+	}
+	var ret time.Time
+	for _, s := range d.Schedule {
+		for t := s.Parsed.Next(from); !t.After(now); t = s.Parsed.Next(t) {
+			switch {
+			case ret.IsZero(),
+				d.Catchup == dag.CatchupAll && t.Before(ret),
+				d.Catchup == dag.CatchupLatest && t.After(ret):
+				ret = t
+			}
+			if d.Catchup == dag.CatchupAll {
+				break
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/database"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/storage"
+	"github.com/yohamta/dagu/internal/suspend"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+func TestMissedSchedule(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	l := &dag.Loader{}
+	d, err := l.LoadData([]byte(`schedule:
+  - "0 * * * *"
+  - "15 * * * *"
+steps:
+  - name: "1"
+    command: "true"
+`))
+	require.NoError(t, err)
+	d.CatchupWindow = time.Hour * 3
+
+	for _, tc := range []struct {
+		policy dag.CatchupPolicy
+		last   time.Time
+		want   time.Time
+	}{
+		{dag.CatchupAll, now.Add(-time.Hour), time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{dag.CatchupAll, now.Add(-time.Hour * 5), time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{dag.CatchupLatest, now.Add(-time.Hour), time.Date(2020, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{dag.CatchupAll, time.Date(2020, 1, 1, 10, 15, 0, 0, time.UTC), time.Time{}},
+		{dag.CatchupLatest, time.Date(2020, 1, 1, 10, 15, 0, 0, time.UTC), time.Time{}},
+	} {
+		d.Catchup = tc.policy
+		require.Equal(t, tc.want, missedSchedule(d, tc.last, now), "%s since %s", tc.policy, tc.last)
+	}
+}
+
+func TestCatchup(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 10, 0, 0, time.Local)
+	utils.FixedTime = now
+	defer func() {
+		utils.FixedTime = time.Time{}
+	}()
+
+	file := path.Join(t.TempDir(), "catchup.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`schedule: "* * * * *"
+catchup: all
+catchupWindowSec: 90
+steps:
+  - name: "1"
+    command: sh
+    script: "echo $DAGU_SCHEDULED_TIME"
+    output: SCHEDULED_TIME
+`), 0644))
+	dr := controller.NewDAGStatusReader()
+	d, err := dr.ReadStatus(file, false)
+	require.NoError(t, err)
+
+	// the last run is scheduled three minutes ago
+	j := &job{DAG: d.DAG, Config: testConfig, Next: now.Add(-time.Minute * 3)}
+	require.NoError(t, j.Start())
+
+	er := &entryReader{
+		Admin: testConfig,
+		suspendChecker: suspend.NewSuspendChecker(
+			storage.NewStorage(
+				settings.MustGet(settings.SETTING__SUSPEND_FLAGS_DIR),
+			),
+		),
+		dagsLock:   sync.Mutex{},
+		dags:       map[string]*dag.DAG{"catchup.yaml": d.DAG},
+		catchingUp: map[string]bool{},
+	}
+	er.catchup()
+
+	entries, err := er.Read(now)
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+
+	require.Eventually(t, func() bool {
+		er.dagsLock.Lock()
+		defer er.dagsLock.Unlock()
+		return len(er.catchingUp) == 0
+	}, time.Second*10, time.Millisecond*100)
+
+	// the schedule two minutes ago is out of the catch-up window
+	c := controller.NewDAGController(d.DAG)
+	var scheduled []string
+	for _, s := range c.GetRecentStatuses(10) {
+		scheduled = append(scheduled, s.Status.Outputs["SCHEDULED_TIME"])
+	}
+	// the runs may start within the same millisecond
+	sort.Strings(scheduled)
+	require.Equal(t, []string{
+		now.Add(-time.Minute * 3).Format(time.RFC3339),
+		now.Add(-time.Minute).Format(time.RFC3339),
+		now.Format(time.RFC3339),
+	}, scheduled)
+
+	entries, err = er.Read(now)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestCatchupSincePreviousDay(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 10, 0, 0, time.Local)
+	utils.FixedTime = now
+	defer func() {
+		utils.FixedTime = time.Time{}
+	}()
+
+	file := path.Join(t.TempDir(), "catchup_day.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`schedule: "* * * * *"
+catchup: all
+catchupWindowSec: 90
+steps:
+  - name: "1"
+    command: sh
+    script: "echo $DAGU_SCHEDULED_TIME"
+    output: SCHEDULED_TIME
+`), 0644))
+	dr := controller.NewDAGStatusReader()
+	d, err := dr.ReadStatus(file, false)
+	require.NoError(t, err)
+
+	// the last run is recorded on the previous day
+	startedAt := time.Now().AddDate(0, 0, -1)
+	w, _, err := database.New().NewWriter(d.DAG.Location, startedAt, "previous-day")
+	require.NoError(t, err)
+	require.NoError(t, w.Open())
+	s := models.NewStatus(d.DAG, nil, scheduler.SchedulerStatus_Success, 0, &startedAt, &startedAt)
+	s.RequestId = "previous-day"
+	s.ScheduledTime = utils.FormatTime(now.Add(-time.Minute * 3))
+	require.NoError(t, w.Write(s))
+	require.NoError(t, w.Close())
+
+	er := &entryReader{
+		Admin: testConfig,
+		suspendChecker: suspend.NewSuspendChecker(
+			storage.NewStorage(
+				settings.MustGet(settings.SETTING__SUSPEND_FLAGS_DIR),
+			),
+		),
+		dagsLock:   sync.Mutex{},
+		dags:       map[string]*dag.DAG{"catchup_day.yaml": d.DAG},
+		catchingUp: map[string]bool{},
+	}
+	er.catchup()
+	require.Eventually(t, func() bool {
+		er.dagsLock.Lock()
+		defer er.dagsLock.Unlock()
+		return len(er.catchingUp) == 0
+	}, time.Second*10, time.Millisecond*100)
+
+	c := controller.NewDAGController(d.DAG)
+	var scheduled []string
+	for _, s := range c.GetRecentStatuses(10) {
+		if s.Status.RequestId != "previous-day" {
+			scheduled = append(scheduled, s.Status.Outputs["SCHEDULED_TIME"])
+		}
+	}
+	sort.Strings(scheduled)
+	require.Equal(t, []string{
+		now.Add(-time.Minute).Format(time.RFC3339),
+		now.Format(time.RFC3339),
+	}, scheduled)
+}
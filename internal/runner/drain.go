@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"log"
+	"time"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/queue"
+	"github.com/yohamta/dagu/internal/scheduler"
+)
+
+// drainInterval is the interval to check the queues of the DAGs.
+var drainInterval = time.Second * 5
+
+// drainQueues keeps starting the runs queued while the DAGs were running.
+func (er *entryReader) drainQueues() {
+	for {
+		er.drain()
+		time.Sleep(drainInterval)
+	}
+}
+
+// drain starts the first run in the queue of each DAG that is not running.
+// The next run of a DAG is not taken until the run started has finished.
+func (er *entryReader) drain() {
+	er.dagsLock.Lock()
+	defer er.dagsLock.Unlock()
+	for file, d := range er.dags {
+		if er.draining[file] || er.suspendChecker.IsSuspended(d) {
+			continue
+		}
+		items, err := er.queue.List(d.Location)
+		if err != nil || len(items) == 0 {
+			continue
+		}
+		status, err := controller.NewDAGController(d).GetStatus()
+		if err != nil || status.Status != scheduler.SchedulerStatus_None {
+			continue
+		}
+		item, err := er.queue.Pop(d.Location)
+		if err != nil {
+			log.Printf("failed to take the queued run of %s: %v", d.Name, err)
+			continue
+		}
+		er.draining[file] = true
+		go er.startQueued(file, d, item)
+	}
+}
+
+func (er *entryReader) startQueued(file string, d *dag.DAG, item *queue.Item) {
+	defer func() {
+		er.dagsLock.Lock()
+		delete(er.draining, file)
+		er.dagsLock.Unlock()
+	}()
+	log.Printf("start %s queued at %s", d.Name, item.QueuedAt.Format("2006-01-02 15:04:05"))
+	err := controller.NewDAGController(d).StartQueued(er.Admin.Command, er.Admin.WorkDir, item)
+	if err != nil {
+		log.Printf("the queued run %s of %s failed: %v", item.RequestId, d.Name, err)
+	}
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/queue"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/storage"
+	"github.com/yohamta/dagu/internal/suspend"
+)
+
+func TestDrain(t *testing.T) {
+	file := path.Join(t.TempDir(), "queue.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`overlapPolicy: queue
+steps:
+  - name: "1"
+    command: echo $1
+    output: PARAM
+`), 0644))
+	dr := controller.NewDAGStatusReader()
+	d, err := dr.ReadStatus(file, false)
+	require.NoError(t, err)
+
+	q := &queue.Queue{Dir: t.TempDir()}
+	for _, id := range []string{"queued-1", "queued-2"} {
+		_, err := q.Push(d.DAG.Location, &queue.Item{RequestId: id, Params: id}, 0)
+		require.NoError(t, err)
+	}
+
+	er := &entryReader{
+		Admin: testConfig,
+		suspendChecker: suspend.NewSuspendChecker(
+			storage.NewStorage(
+				settings.MustGet(settings.SETTING__SUSPEND_FLAGS_DIR),
+			),
+		),
+		dagsLock:   sync.Mutex{},
+		dags:       map[string]*dag.DAG{"queue.yaml": d.DAG},
+		catchingUp: map[string]bool{},
+		queue:      q,
+		draining:   map[string]bool{},
+	}
+
+	c := controller.NewDAGController(d.DAG)
+	for _, id := range []string{"queued-1", "queued-2"} {
+		er.drain()
+		// the next run is not taken until the run has finished
+		er.drain()
+		require.Eventually(t, func() bool {
+			er.dagsLock.Lock()
+			defer er.dagsLock.Unlock()
+			return len(er.draining) == 0
+		}, time.Second*5, time.Millisecond*100)
+
+		s, err := c.GetStatusByRequestId(id)
+		require.NoError(t, err)
+		require.Equal(t, scheduler.SchedulerStatus_Success, s.Status)
+		require.Equal(t, id, s.Outputs["PARAM"])
+	}
+
+	items, err := q.List(d.DAG.Location)
+	require.NoError(t, err)
+	require.Len(t, items, 0)
+}
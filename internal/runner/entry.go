@@ -1,16 +1,21 @@
 package runner
 
 import (
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/robfig/cron/v3"
 	"github.com/yohamta/dagu/internal/admin"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/queue"
 	"github.com/yohamta/dagu/internal/runner/filenotify"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/storage"
@@ -30,6 +35,29 @@ type Entry struct {
 	Next      time.Time
 	Job       Job
 	EntryType EntryType
+	// Schedule is the schedule to run the entry again. The entry runs only
+	// once if it is nil.
+	Schedule cron.Schedule
+	// Key identifies the schedule of the entry among the entries read
+	// again. The entry is not identified if it is empty.
+	Key string
+}
+
+// next returns the entry to run at the next time of the schedule after now,
+// or nil if it does not run again.
+func (e *Entry) next(now time.Time) *Entry {
+	if e.Schedule == nil {
+		return nil
+	}
+	t := e.Schedule.Next(now)
+	if t.IsZero() {
+		return nil
+	}
+	j := e.Job
+	if sj, ok := j.(scheduledJob); ok {
+		j = sj.at(t)
+	}
+	return &Entry{Next: t, Job: j, EntryType: e.EntryType, Schedule: e.Schedule, Key: e.Key}
 }
 
 func (e *Entry) Invoke() error {
@@ -62,8 +90,13 @@ func newEntryReader(cfg *admin.Config) *entryReader {
 				settings.MustGet(settings.SETTING__SUSPEND_FLAGS_DIR),
 			),
 		),
-		dagsLock: sync.Mutex{},
-		dags:     map[string]*dag.DAG{},
+		dagsLock:   sync.Mutex{},
+		dags:       map[string]*dag.DAG{},
+		catchingUp: map[string]bool{},
+		queue:      queue.New(),
+		draining:   map[string]bool{},
+		triggered:  map[string]string{},
+		filesDir:   settings.MustGet(settings.SETTING__TRIGGERS_DIR),
 	}
 	if err := er.initDags(); err != nil {
 		log.Printf("failed to init entry dags %v", err)
@@ -77,6 +110,17 @@ type entryReader struct {
 	suspendChecker *suspend.SuspendChecker
 	dagsLock       sync.Mutex
 	dags           map[string]*dag.DAG
+	// catchingUp is the set of the DAGs running their missed schedules.
+	catchingUp map[string]bool
+	queue      *queue.Queue
+	// draining is the set of the DAGs running a run taken from the queue.
+	draining map[string]bool
+	// triggered is the request ID of the last run checked for each trigger
+	// of the DAGs.
+	triggered map[string]string
+	// filesDir is the directory of the flags of the files that have
+	// triggered the DAGs.
+	filesDir string
 }
 
 var _ EntryReader = (*entryReader)(nil)
@@ -90,22 +134,26 @@ func (er *entryReader) Read(now time.Time) ([]*Entry, error) {
 		for _, ss := range s {
 			next := ss.Parsed.Next(now)
 			entries = append(entries, &Entry{
-				Next: ss.Parsed.Next(now),
+				Next: next,
 				Job: &job{
 					DAG:    d,
 					Config: er.Admin,
 					Next:   next,
 				},
 				EntryType: e,
+				Schedule:  ss.Parsed,
+				Key:       fmt.Sprintf("%s\t%d\t%s\t%s", d.Location, e, ss.Expression, ss.Timezone),
 			})
 		}
 	}
 
-	for _, d := range er.dags {
+	for file, d := range er.dags {
 		if er.suspendChecker.IsSuspended(d) {
 			continue
 		}
-		f(d, d.Schedule, EntryTypeStart)
+		if !er.catchingUp[file] {
+			f(d, d.Schedule, EntryTypeStart)
+		}
 		f(d, d.StopSchedule, EntryTypeStop)
 		f(d, d.RestartSchedule, EntryTypeRestart)
 	}
@@ -116,57 +164,89 @@ func (er *entryReader) Read(now time.Time) ([]*Entry, error) {
 func (er *entryReader) initDags() error {
 	er.dagsLock.Lock()
 	defer er.dagsLock.Unlock()
-	cl := dag.Loader{}
-	fis, err := os.ReadDir(er.Admin.DAGs)
+	files, err := dag.FindFiles(er.Admin.DAGs)
 	if err != nil {
 		return err
 	}
-	fileNames := []string{}
-	for _, fi := range fis {
-		if utils.MatchExtension(fi.Name(), dag.EXTENSIONS) {
-			dag, err := cl.LoadHeadOnly(filepath.Join(er.Admin.DAGs, fi.Name()))
-			if err != nil {
-				log.Printf("init dags failed to read dag config: %s", err)
-				continue
-			}
-			er.dags[fi.Name()] = dag
-			fileNames = append(fileNames, fi.Name())
-		}
+	for _, f := range files {
+		er.loadDag(f)
 	}
-	log.Printf("init scheduler dags: %s", strings.Join(fileNames, ","))
+	log.Printf("init scheduler dags: %s", strings.Join(files, ","))
 	return nil
 }
 
-func (er *entryReader) watchDags() {
+// loadDag loads the DAG of the file relative to the DAGs directory.
+func (er *entryReader) loadDag(file string) {
 	cl := dag.Loader{}
+	d, err := cl.LoadHeadOnly(filepath.Join(er.Admin.DAGs, file))
+	if err != nil {
+		log.Printf("failed to read dag config: %s", err)
+		return
+	}
+	d.SetDAGsDir(er.Admin.DAGs)
+	er.dags[file] = d
+}
+
+// removeDags removes the DAG of the file or the DAGs in the directory
+// relative to the DAGs directory.
+func (er *entryReader) removeDags(name string) {
+	for file := range er.dags {
+		if file == name || strings.HasPrefix(file, name+"/") {
+			delete(er.dags, file)
+			log.Printf("remove dag entry %s", file)
+		}
+	}
+}
+
+// watchDir adds the directory and its subdirectories to the watcher.
+func (er *entryReader) watchDir(watcher filenotify.FileWatcher, dir string) {
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		utils.LogErr("watch dags directory", watcher.Add(p))
+		return nil
+	})
+}
+
+func (er *entryReader) watchDags() {
 	watcher, err := filenotify.New(time.Minute)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer watcher.Close()
-	watcher.Add(er.Admin.DAGs)
+	er.watchDir(watcher, er.Admin.DAGs)
 	for {
 		select {
 		case event, ok := <-watcher.Events():
 			if !ok {
 				return
 			}
-			if !utils.MatchExtension(event.Name, dag.EXTENSIONS) {
+			rel, err := filepath.Rel(er.Admin.DAGs, event.Name)
+			if err != nil {
 				continue
 			}
+			rel = filepath.ToSlash(rel)
+			fi, _ := os.Stat(event.Name)
 			er.dagsLock.Lock()
-			if event.Op == fsnotify.Create || event.Op == fsnotify.Write {
-				dag, err := cl.LoadHeadOnly(filepath.Join(er.Admin.DAGs, filepath.Base(event.Name)))
-				if err != nil {
-					log.Printf("failed to read dag config: %s", err)
-				} else {
-					er.dags[filepath.Base(event.Name)] = dag
-					log.Printf("reload dag entry %s", event.Name)
+			if event.Op == fsnotify.Create && fi != nil && fi.IsDir() {
+				// load the DAGs in the directory moved into the DAGs directory
+				er.watchDir(watcher, event.Name)
+				files, _ := dag.FindFiles(event.Name)
+				for _, f := range files {
+					er.loadDag(path.Join(rel, f))
+					log.Printf("reload dag entry %s", path.Join(rel, f))
 				}
+			} else if utils.MatchExtension(event.Name, dag.EXTENSIONS) &&
+				(event.Op == fsnotify.Create || event.Op == fsnotify.Write) {
+				er.loadDag(rel)
+				log.Printf("reload dag entry %s", event.Name)
 			}
 			if event.Op == fsnotify.Rename || event.Op == fsnotify.Remove {
-				delete(er.dags, filepath.Base(event.Name))
-				log.Printf("remove dag entry %s", event.Name)
+				er.removeDags(rel)
 			}
 			er.dagsLock.Unlock()
 		case err, ok := <-watcher.Errors():
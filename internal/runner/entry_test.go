@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"os"
 	"path"
 	"testing"
 	"time"
@@ -54,3 +55,36 @@ func TestReadEntries(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, len(entries)-1, len(lives))
 }
+
+func TestReadEntriesSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	spec := []byte("schedule: 0 * * * *\nsteps:\n  - name: step 1\n    command: \"true\"\n")
+	write := func(f string) {
+		p := path.Join(dir, f)
+		require.NoError(t, os.MkdirAll(path.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, spec, 0644))
+	}
+	write("team-a/etl.yaml")
+	write("team-b/etl.yaml")
+
+	r := newEntryReader(&admin.Config{DAGs: dir})
+	entries, err := r.Read(time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	groups := []string{}
+	for _, e := range entries {
+		groups = append(groups, e.Job.(*job).DAG.Group)
+	}
+	require.ElementsMatch(t, []string{"team-a", "team-b"}, groups)
+
+	count := func() int {
+		entries, _ := r.Read(time.Now())
+		return len(entries)
+	}
+	// wait for the watcher to start
+	time.Sleep(time.Millisecond * 500)
+	write("team-c/nested/etl.yaml")
+	require.Eventually(t, func() bool { return count() == 3 }, time.Second*5, time.Millisecond*100)
+	require.NoError(t, os.RemoveAll(path.Join(dir, "team-a")))
+	require.Eventually(t, func() bool { return count() == 2 }, time.Second*5, time.Millisecond*100)
+}
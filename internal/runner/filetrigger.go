@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/runner/filenotify"
+	"github.com/yohamta/dagu/internal/storage"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// fileTriggerInterval is the interval to check the files of the file
+// triggers in addition to the changes notified by the watcher.
+var fileTriggerInterval = time.Second * 5
+
+// watchFiles keeps starting the DAGs triggered by the files arrived. The
+// directories of the files are watched to find a new file without waiting
+// for the next check.
+func (er *entryReader) watchFiles() {
+	watcher, err := filenotify.New(fileTriggerInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer watcher.Close()
+	watched := map[string]bool{}
+	for {
+		er.dagsLock.Lock()
+		er.watchFileDirs(watcher, watched)
+		er.checkFiles()
+		er.dagsLock.Unlock()
+		select {
+		case _, ok := <-watcher.Events():
+			if !ok {
+				return
+			}
+		case err, ok := <-watcher.Errors():
+			if !ok {
+				return
+			}
+			log.Println("watch trigger files error:", err)
+		case <-time.After(fileTriggerInterval):
+		}
+	}
+}
+
+// watchFileDirs adds the directories of the file triggers to the watcher
+// and removes the ones no longer used. A directory with a glob pattern is
+// not watched and is only checked at the interval.
+func (er *entryReader) watchFileDirs(watcher filenotify.FileWatcher, watched map[string]bool) {
+	dirs := map[string]bool{}
+	for _, d := range er.dags {
+		for _, t := range d.Triggers {
+			if t.File == nil {
+				continue
+			}
+			dir := filepath.Dir(triggerFilePath(d, t.File))
+			if !strings.ContainsAny(dir, "*?[\\") {
+				dirs[dir] = true
+			}
+		}
+	}
+	for dir := range dirs {
+		if !watched[dir] && watcher.Add(dir) == nil {
+			watched[dir] = true
+		}
+	}
+	for dir := range watched {
+		if !dirs[dir] {
+			utils.LogErr("unwatch trigger files directory", watcher.Remove(dir))
+			delete(watched, dir)
+		}
+	}
+}
+
+// checkFiles starts the DAGs for the files matching their file triggers
+// that have not triggered them yet. A file triggers the DAG once it has
+// been left unmodified for the stable time of the trigger. The flag of
+// the file is kept until the file is removed, so that the file triggers
+// the DAG only once even after the scheduler restarts.
+func (er *entryReader) checkFiles() {
+	now := time.Now()
+	for _, d := range er.dags {
+		if !hasFileTrigger(d) {
+			continue
+		}
+		matched := map[string]bool{}
+		ready := []string{}
+		for _, t := range d.Triggers {
+			if t.File == nil {
+				continue
+			}
+			files, err := filepath.Glob(triggerFilePath(d, t.File))
+			if err != nil {
+				log.Printf("failed to find the trigger files of %s: %v", d.Name, err)
+				continue
+			}
+			for _, f := range files {
+				fi, err := os.Stat(f)
+				if err != nil || !fi.Mode().IsRegular() || matched[flagName(f)] {
+					continue
+				}
+				matched[flagName(f)] = true
+				if now.Sub(fi.ModTime()) >= t.File.Stable {
+					ready = append(ready, f)
+				}
+			}
+		}
+		flags := storage.NewStorage(er.filesFlagDir(d))
+		if !er.suspendChecker.IsSuspended(d) {
+			for _, f := range ready {
+				if flags.Exists(flagName(f)) {
+					continue
+				}
+				if err := flags.Create(flagName(f)); err != nil {
+					log.Printf("failed to save the trigger file %s: %v", f, err)
+					continue
+				}
+				go er.startFileTriggered(d, f)
+			}
+		}
+		entries, _ := os.ReadDir(flags.Dir)
+		for _, e := range entries {
+			if !matched[e.Name()] {
+				utils.LogErr("remove the flag of the trigger file", flags.Delete(e.Name()))
+			}
+		}
+	}
+}
+
+func hasFileTrigger(d *dag.DAG) bool {
+	for _, t := range d.Triggers {
+		if t.File != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// filesFlagDir returns the directory of the flags of the files that have
+// triggered the DAG.
+func (er *entryReader) filesFlagDir(d *dag.DAG) string {
+	h := md5.Sum([]byte(d.Location))
+	return filepath.Join(er.filesDir,
+		fmt.Sprintf("%s-%s", utils.ValidFilename(d.Name, "_"), hex.EncodeToString(h[:])))
+}
+
+// startFileTriggered starts the DAG with the path of the file as the
+// parameter.
+func (er *entryReader) startFileTriggered(d *dag.DAG, file string) {
+	log.Printf("start %s triggered by %s", d.Name, file)
+	c := controller.NewDAGController(d)
+	if err := c.Start(er.Admin.Command, er.Admin.WorkDir, file); err != nil {
+		log.Printf("the run of %s triggered by %s failed: %v", d.Name, file, err)
+	}
+}
+
+// triggerFilePath returns the pattern of the files of the trigger. It is
+// relative to the directory of the DAG file unless it is absolute.
+func triggerFilePath(d *dag.DAG, t *dag.FileTrigger) string {
+	if filepath.IsAbs(t.Path) {
+		return t.Path
+	}
+	return filepath.Join(filepath.Dir(d.Location), t.Path)
+}
+
+func flagName(file string) string {
+	h := md5.Sum([]byte(file))
+	return hex.EncodeToString(h[:])
+}
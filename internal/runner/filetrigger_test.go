@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/storage"
+	"github.com/yohamta/dagu/internal/suspend"
+)
+
+func TestCheckFiles(t *testing.T) {
+	dir := t.TempDir()
+	inbound := path.Join(dir, "inbound")
+	require.NoError(t, os.Mkdir(inbound, 0755))
+	file := path.Join(dir, "inbound.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`triggers:
+  - file:
+      path: inbound/*.csv
+      stableSec: 60
+steps:
+  - name: "1"
+    command: echo $1
+    output: FILE
+`), 0644))
+	dr := controller.NewDAGStatusReader()
+	d, err := dr.ReadStatus(file, true)
+	require.NoError(t, err)
+
+	er := &entryReader{
+		Admin: testConfig,
+		suspendChecker: suspend.NewSuspendChecker(
+			storage.NewStorage(
+				settings.MustGet(settings.SETTING__SUSPEND_FLAGS_DIR),
+			),
+		),
+		dagsLock: sync.Mutex{},
+		dags:     map[string]*dag.DAG{"inbound.yaml": d.DAG},
+		filesDir: t.TempDir(),
+	}
+
+	c := controller.NewDAGController(d.DAG)
+	waitRuns := func(n int) {
+		require.Eventually(t, func() bool {
+			runs := c.GetRecentStatuses(n + 1)
+			return len(runs) == n && runs[0].Status.Status == scheduler.SchedulerStatus_Success
+		}, time.Second*5, time.Millisecond*100)
+	}
+	arrive := func(name string) string {
+		f := path.Join(inbound, name)
+		require.NoError(t, os.WriteFile(f, []byte("a,b"), 0644))
+		return f
+	}
+	stable := func(f string) {
+		old := time.Now().Add(-time.Minute)
+		require.NoError(t, os.Chtimes(f, old, old))
+	}
+
+	// the file still being written does not start the DAG
+	f := arrive("1.csv")
+	arrive("1.txt")
+	er.checkFiles()
+	time.Sleep(time.Millisecond * 500)
+	require.Len(t, c.GetRecentStatuses(1), 0)
+
+	stable(f)
+	er.checkFiles()
+	waitRuns(1)
+	require.Equal(t, f, c.GetRecentStatuses(1)[0].Status.Outputs["FILE"])
+
+	// the file triggers the DAG only once
+	er.checkFiles()
+	time.Sleep(time.Millisecond * 500)
+	require.Len(t, c.GetRecentStatuses(2), 1)
+
+	// the file arrived again after it is removed starts the DAG again
+	require.NoError(t, os.Remove(f))
+	er.checkFiles()
+	stable(arrive("1.csv"))
+	er.checkFiles()
+	waitRuns(2)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/yohamta/dagu/internal/admin"
 	"github.com/yohamta/dagu/internal/controller"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/models"
 	"github.com/yohamta/dagu/internal/scheduler"
 	"github.com/yohamta/dagu/internal/utils"
 )
@@ -26,6 +27,14 @@ type job struct {
 
 var _ Job = (*job)(nil)
 
+// scheduledJob is a job that depends on the time it is scheduled at.
+type scheduledJob interface {
+	// at returns the job scheduled at the time.
+	at(next time.Time) Job
+}
+
+var _ scheduledJob = (*job)(nil)
+
 var (
 	ErrJobRunning      = errors.New("job already running")
 	ErrJobIsNotRunning = errors.New("job is not running")
@@ -40,21 +49,39 @@ func (j *job) Start() error {
 	}
 	switch s.Status {
 	case scheduler.SchedulerStatus_Running:
-		// already running
-		return ErrJobRunning
+		if j.DAG.OverlapPolicy == dag.OverlapSkip {
+			return ErrJobRunning
+		}
+		// the run is queued or the running one is stopped by the agent
 	case scheduler.SchedulerStatus_None:
 	default:
 		// check the last execution time
-		t, err := utils.ParseTime(s.StartedAt)
+		t, err := lastRunTime(s)
 		if err == nil {
-			t = t.Truncate(time.Second * 60)
+			t = t.Truncate(time.Second)
 			if t.After(j.Next) || j.Next.Equal(t) {
 				return ErrJobFinished
 			}
 		}
 		// should not be here
 	}
-	return c.Start(j.Config.Command, j.Config.WorkDir, "")
+	return c.StartScheduled(j.Config.Command, j.Config.WorkDir, j.Next)
+}
+
+// lastRunTime returns the time the run is scheduled at, or the time it
+// started if it was not started by the scheduler. The time a backfill run
+// is scheduled at is in the past, so the time it started is used.
+func lastRunTime(s *models.Status) (time.Time, error) {
+	if s.ScheduledTime != "" && s.BackfillId == "" {
+		return utils.ParseTime(s.ScheduledTime)
+	}
+	return utils.ParseTime(s.StartedAt)
+}
+
+func (j *job) at(next time.Time) Job {
+	ret := *j
+	ret.Next = next
+	return &ret
 }
 
 func (j *job) Stop() error {
@@ -0,0 +1,34 @@
+package runner
+
+import "time"
+
+// entryQueue is a priority queue of the entries in the order of the next
+// time to run. It implements heap.Interface.
+type entryQueue []*Entry
+
+func (q entryQueue) Len() int { return len(q) }
+
+func (q entryQueue) Less(i, j int) bool { return q[i].Next.Before(q[j].Next) }
+
+func (q entryQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *entryQueue) Push(x interface{}) {
+	*q = append(*q, x.(*Entry))
+}
+
+func (q *entryQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return e
+}
+
+// peek returns the time of the first entry, or false if the queue is empty.
+func (q entryQueue) peek() (time.Time, bool) {
+	if len(q) == 0 {
+		return time.Time{}, false
+	}
+	return q[0].Next, true
+}
@@ -1,17 +1,25 @@
 package runner
 
 import (
+	"container/heap"
 	"log"
-	"sort"
 	"time"
 
 	"github.com/yohamta/dagu/internal/utils"
 )
 
+// Runner runs the entries at their next times. The entries are kept in a
+// priority queue and read again from the EntryReader every minute to follow
+// the changes of the DAGs.
 type Runner struct {
 	entryReader EntryReader
 	running     bool
 	stop        chan struct{}
+	queue       entryQueue
+	// last is the time until which the entries have been run.
+	last time.Time
+	// refreshAt is the time to read the entries again.
+	refreshAt time.Time
 }
 
 func New(er EntryReader) *Runner {
@@ -23,14 +31,15 @@ func New(er EntryReader) *Runner {
 
 func (r *Runner) Start() {
 	r.init()
-	t := utils.Now().Truncate(time.Second * 60)
+	now := utils.Now()
+	r.last = now.Add(-time.Second)
+	r.refreshAt = now
 	timer := time.NewTimer(0)
 	for {
 		select {
 		case <-timer.C:
-			r.run(t)
-			t = r.nextTick(t)
-			timer = time.NewTimer(t.Sub(utils.Now()))
+			next := r.wake(utils.Now())
+			timer = time.NewTimer(next.Sub(utils.Now()))
 		case <-r.stop:
 			_ = timer.Stop()
 			return
@@ -38,28 +47,68 @@ func (r *Runner) Start() {
 	}
 }
 
+// wake reads the entries again if it is time to, runs the entries whose
+// next times have come, and returns the time to wake up next.
+func (r *Runner) wake(now time.Time) time.Time {
+	if !now.Before(r.refreshAt) {
+		r.refresh()
+		r.refreshAt = r.nextTick(now)
+	}
+	r.run(now)
+	next := r.refreshAt
+	if t, ok := r.queue.peek(); ok && t.Before(next) {
+		next = t
+	}
+	return next
+}
+
 func (r *Runner) init() {
 	r.running = true
 }
 
-func (r *Runner) run(now time.Time) {
-	entries, err := r.entryReader.Read(now.Add(-time.Second))
+// refresh reads the entries to run after the last run. An entry already
+// in the queue keeps its next time, as the next time of a schedule such as
+// "@every 5m" depends on the time it is computed from.
+func (r *Runner) refresh() {
+	entries, err := r.entryReader.Read(r.last)
 	utils.LogErr("failed to read entries", err)
-	sort.SliceStable(entries, func(i, j int) bool {
-		return entries[i].Next.Before(entries[j].Next)
-	})
+	queued := map[string]*Entry{}
+	for _, e := range r.queue {
+		if e.Key != "" {
+			queued[e.Key] = e
+		}
+	}
 	for _, e := range entries {
-		t := e.Next
-		if t.After(now) {
+		if q, ok := queued[e.Key]; ok && e.Key != "" {
+			e.Next = q.Next
+			if sj, ok := e.Job.(scheduledJob); ok {
+				e.Job = sj.at(q.Next)
+			}
+		}
+	}
+	r.queue = append(entryQueue{}, entries...)
+	heap.Init(&r.queue)
+}
+
+// run invokes the entries whose next times have come and puts them back
+// to the queue with the following times of their schedules.
+func (r *Runner) run(now time.Time) {
+	for {
+		if t, ok := r.queue.peek(); !ok || t.After(now) {
 			break
 		}
+		e := heap.Pop(&r.queue).(*Entry)
 		go func(e *Entry) {
 			err := e.Invoke()
 			if err != nil {
 				log.Printf("runner: entry failed %s: %v", e.Job, err)
 			}
 		}(e)
+		if next := e.next(now); next != nil {
+			heap.Push(&r.queue, next)
+		}
 	}
+	r.last = now
 }
 
 func (r *Runner) nextTick(now time.Time) time.Time {
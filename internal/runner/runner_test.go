@@ -1,11 +1,14 @@
 package runner
 
 import (
+	"container/heap"
 	"os"
 	"path"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/yohamta/dagu/internal/admin"
@@ -83,6 +86,81 @@ func TestRestart(t *testing.T) {
 	require.Equal(t, 1, er.Entries[0].Job.(*mockJob).RestartCount)
 }
 
+func TestRunSchedule(t *testing.T) {
+	utils.FixedTime = time.Time{}
+	now := utils.Now().Truncate(time.Second)
+
+	er := &mockEntryReader{
+		Entries: []*Entry{
+			{
+				Job:      &mockJob{},
+				Next:     now,
+				Schedule: cron.Every(time.Second),
+			},
+			{
+				Job:      &mockJob{},
+				Next:     now.Add(time.Minute),
+				Schedule: cron.Every(time.Second),
+			},
+		},
+	}
+
+	r := New(er)
+
+	go func() {
+		r.Start()
+	}()
+
+	time.Sleep(time.Millisecond * 2500)
+	r.Stop()
+
+	require.GreaterOrEqual(t, er.Entries[0].Job.(*mockJob).RunCount, 2)
+	require.Equal(t, 0, er.Entries[1].Job.(*mockJob).RunCount)
+}
+
+func TestRunEverySchedule(t *testing.T) {
+	for _, test := range []struct {
+		Every time.Duration
+		Want  int32
+	}{
+		{time.Second * 90, 26},
+		{time.Minute * 5, 8},
+	} {
+		start := time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC)
+		j := &countJob{}
+		r := New(&scheduleEntryReader{Schedule: cron.Every(test.Every), Job: j})
+		r.last = start.Add(-time.Second)
+		r.refreshAt = start
+
+		// the runner wakes up at the times it asks for, including the
+		// times to read the entries again every minute
+		for now := start; now.Before(start.Add(time.Minute * 40)); {
+			now = r.wake(now)
+		}
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&j.count) == test.Want
+		}, time.Second, time.Millisecond*10, "every %s", test.Every)
+	}
+}
+
+func TestEntryQueue(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := &entryQueue{}
+	for _, d := range []int{3, 1, 4, 1, 5, 9, 2, 6} {
+		heap.Push(q, &Entry{Next: now.Add(time.Second * time.Duration(d))})
+	}
+	next, ok := q.peek()
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Second), next)
+
+	var ret []int
+	for q.Len() > 0 {
+		e := heap.Pop(q).(*Entry)
+		ret = append(ret, int(e.Next.Sub(now)/time.Second))
+	}
+	require.Equal(t, []int{1, 1, 2, 3, 4, 5, 6, 9}, ret)
+}
+
 func TestNextTick(t *testing.T) {
 	n := time.Date(2020, 1, 1, 1, 0, 50, 0, time.UTC)
 	utils.FixedTime = n
@@ -101,6 +179,41 @@ func (er *mockEntryReader) Read(now time.Time) ([]*Entry, error) {
 	return er.Entries, nil
 }
 
+// scheduleEntryReader reads the entry of the schedule to run after the
+// time like the entry reader of the DAGs.
+type scheduleEntryReader struct {
+	Schedule cron.Schedule
+	Job      Job
+}
+
+var _ EntryReader = (*scheduleEntryReader)(nil)
+
+func (er *scheduleEntryReader) Read(now time.Time) ([]*Entry, error) {
+	return []*Entry{{
+		Next:     er.Schedule.Next(now),
+		Job:      er.Job,
+		Schedule: er.Schedule,
+		Key:      "schedule",
+	}}, nil
+}
+
+type countJob struct {
+	count int32
+}
+
+var _ Job = (*countJob)(nil)
+
+func (j *countJob) String() string { return "count" }
+
+func (j *countJob) Start() error {
+	atomic.AddInt32(&j.count, 1)
+	return nil
+}
+
+func (j *countJob) Stop() error { return nil }
+
+func (j *countJob) Restart() error { return nil }
+
 type mockJob struct {
 	Name         string
 	RunCount     int
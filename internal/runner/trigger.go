@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/sensor"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// triggerInterval is the interval to check the runs of the DAGs that
+// trigger other DAGs.
+var triggerInterval = time.Second * 5
+
+// watchTriggers keeps starting the DAGs triggered by the runs of other DAGs.
+func (er *entryReader) watchTriggers() {
+	for {
+		er.checkTriggers()
+		time.Sleep(triggerInterval)
+	}
+}
+
+// checkTriggers starts the DAGs whose trigger DAG has a new run ended with
+// the status of the trigger. The runs ended before the first check do not
+// start the DAGs.
+func (er *entryReader) checkTriggers() {
+	er.dagsLock.Lock()
+	defer er.dagsLock.Unlock()
+	for file, d := range er.dags {
+		for _, t := range d.Triggers {
+			if t.DAG == nil {
+				continue
+			}
+			location := dag.ResolveFile(filepath.Dir(d.Location), t.DAG.Name)
+			key := file + "\x00" + location
+			last, seen := er.triggered[key]
+			s := sensor.LatestRun(location, "")
+			if s == nil || s.Status == scheduler.SchedulerStatus_Running {
+				if !seen {
+					er.triggered[key] = ""
+				}
+				continue
+			}
+			er.triggered[key] = s.RequestId
+			if !seen || s.RequestId == last || !sensor.Matches(s, t.DAG.Status) {
+				continue
+			}
+			if er.suspendChecker.IsSuspended(d) {
+				continue
+			}
+			go er.startTriggered(d, s)
+		}
+	}
+}
+
+// startTriggered starts the DAG triggered by the run of another DAG. The
+// run is given the scheduled time of the other run if any so that both
+// runs have the same logical date.
+func (er *entryReader) startTriggered(d *dag.DAG, s *models.Status) {
+	log.Printf("start %s triggered by %s %s", d.Name, s.Name, s.RequestId)
+	c := controller.NewDAGController(d)
+	var err error
+	if t, perr := utils.ParseTime(s.ScheduledTime); s.ScheduledTime != "" && perr == nil {
+		err = c.StartScheduled(er.Admin.Command, er.Admin.WorkDir, t)
+	} else {
+		err = c.Start(er.Admin.Command, er.Admin.WorkDir, "")
+	}
+	if err != nil {
+		log.Printf("the run of %s triggered by %s failed: %v", d.Name, s.Name, err)
+	}
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yohamta/dagu/internal/controller"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/database"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/storage"
+	"github.com/yohamta/dagu/internal/suspend"
+)
+
+func TestCheckTriggers(t *testing.T) {
+	dir := t.TempDir()
+	file := path.Join(dir, "downstream.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`triggers:
+  - dag:
+      name: upstream
+steps:
+  - name: "1"
+    command: "true"
+`), 0644))
+	dr := controller.NewDAGStatusReader()
+	d, err := dr.ReadStatus(file, true)
+	require.NoError(t, err)
+
+	er := &entryReader{
+		Admin: testConfig,
+		suspendChecker: suspend.NewSuspendChecker(
+			storage.NewStorage(
+				settings.MustGet(settings.SETTING__SUSPEND_FLAGS_DIR),
+			),
+		),
+		dagsLock:  sync.Mutex{},
+		dags:      map[string]*dag.DAG{"downstream.yaml": d.DAG},
+		triggered: map[string]string{},
+	}
+
+	upstream := &dag.DAG{Name: "upstream", Location: path.Join(dir, "upstream.yaml")}
+	// the history is ordered by the time in seconds
+	startedAt := time.Now()
+	writeRun := func(requestId string, status scheduler.SchedulerStatus) {
+		startedAt = startedAt.Add(time.Second)
+		w, _, err := database.New().NewWriter(upstream.Location, startedAt, requestId)
+		require.NoError(t, err)
+		require.NoError(t, w.Open())
+		defer w.Close()
+		s := models.NewStatus(upstream, nil, status, 0, &startedAt, &startedAt)
+		s.RequestId = requestId
+		require.NoError(t, w.Write(s))
+	}
+
+	c := controller.NewDAGController(d.DAG)
+	lastStatus := func() scheduler.SchedulerStatus {
+		s, err := c.GetLastStatus()
+		require.NoError(t, err)
+		return s.Status
+	}
+
+	// the run ended before the first check does not start the DAG
+	writeRun("run-1", scheduler.SchedulerStatus_Success)
+	er.checkTriggers()
+	time.Sleep(time.Millisecond * 500)
+	require.Equal(t, scheduler.SchedulerStatus_None, lastStatus())
+
+	// the run of the other status does not start the DAG
+	writeRun("run-2", scheduler.SchedulerStatus_Error)
+	er.checkTriggers()
+	time.Sleep(time.Millisecond * 500)
+	require.Equal(t, scheduler.SchedulerStatus_None, lastStatus())
+
+	writeRun("run-3", scheduler.SchedulerStatus_Success)
+	er.checkTriggers()
+	require.Eventually(t, func() bool {
+		return lastStatus() == scheduler.SchedulerStatus_Success
+	}, time.Second*5, time.Millisecond*100)
+}
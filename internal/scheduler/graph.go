@@ -1,8 +1,11 @@
 package scheduler
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +17,7 @@ type ExecutionGraph struct {
 	StartedAt       time.Time
 	FinishedAt      time.Time
 	outputVariables *sync.Map
+	mu              sync.RWMutex
 	dict            map[int]*Node
 	nodes           []*Node
 	from            map[int][]int
@@ -43,7 +47,8 @@ func NewExecutionGraph(steps ...*dag.Step) (*ExecutionGraph, error) {
 }
 
 // NewExecutionGraphForRetry creates a new execution graph for retry with given nodes.
-func NewExecutionGraphForRetry(nodes ...*Node) (*ExecutionGraph, error) {
+// The outputs of the previous run are passed to the steps that are not run again.
+func NewExecutionGraphForRetry(outputs map[string]string, nodes ...*Node) (*ExecutionGraph, error) {
 	graph := &ExecutionGraph{
 		outputVariables: &sync.Map{},
 		dict:            make(map[int]*Node),
@@ -63,6 +68,10 @@ func NewExecutionGraphForRetry(nodes ...*Node) (*ExecutionGraph, error) {
 	if err := graph.setupRetry(); err != nil {
 		return nil, err
 	}
+	if err := graph.setupParallelRetry(); err != nil {
+		return nil, err
+	}
+	graph.restoreOutputs(outputs)
 	return graph, nil
 }
 
@@ -76,13 +85,132 @@ func (g *ExecutionGraph) Duration() time.Duration {
 
 // Nodes returns the nodes of the execution graph.
 func (g *ExecutionGraph) Nodes() []*Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.nodes
 }
 
 func (g *ExecutionGraph) node(id int) *Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.dict[id]
 }
 
+// expand adds a node for each item of the parallel step to the graph.
+// The parallel node keeps running until all of the expanded nodes finish.
+// The items must be unique as the expanded nodes are named after them.
+func (g *ExecutionGraph) expand(node *Node) error {
+	items, err := g.parallelItems(node)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, item := range items {
+		if seen[item] {
+			return fmt.Errorf("duplicate item for parallel: %s", item)
+		}
+		seen[item] = true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, item := range items {
+		step := *node.Step
+		step.Name = fmt.Sprintf("%s[%s]", node.Name, item)
+		step.Depends = nil
+		step.Preconditions = nil
+		step.When = nil
+		step.Parallel = nil
+		step.Variables = append(append([]string{}, node.Variables...),
+			fmt.Sprintf("%s=%s", dag.ParallelItemEnv, item))
+		step.CmdWithArgs = expandItem(node.CmdWithArgs, item)
+		step.Params = expandItem(node.Params, item)
+		child := &Node{Step: &step}
+		child.ParallelParent = node.Name
+		child.parent = node
+		child.init()
+		node.children = append(node.children, child)
+		g.dict[child.id] = child
+		g.nodes = append(g.nodes, child)
+	}
+	return nil
+}
+
+// expandItem replaces the item variable in s leaving other variables as is.
+func expandItem(s, item string) string {
+	return os.Expand(s, func(key string) string {
+		if key == dag.ParallelItemEnv {
+			return item
+		}
+		return fmt.Sprintf("${%s}", key)
+	})
+}
+
+// Outputs returns the values of the output variables of the steps.
+func (g *ExecutionGraph) Outputs() map[string]string {
+	ret := map[string]string{}
+	g.outputVariables.Range(func(key, value interface{}) bool {
+		ret[key.(string)] = strings.TrimPrefix(value.(string), fmt.Sprintf("%s=", key))
+		return true
+	})
+	return ret
+}
+
+// parallelItems returns the items of the parallel step. The items in
+// a variable are read as a JSON array, or split by white spaces.
+func (g *ExecutionGraph) parallelItems(node *Node) ([]string, error) {
+	cfg := node.Parallel
+	if cfg.Variable == "" {
+		return cfg.Items, nil
+	}
+	val, ok := node.LookupVariable(cfg.Variable)
+	if !ok {
+		return nil, fmt.Errorf("variable for parallel not found: %s", cfg.Variable)
+	}
+	list := []interface{}{}
+	if err := json.Unmarshal([]byte(val), &list); err != nil {
+		return strings.Fields(val), nil
+	}
+	items := []string{}
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			items = append(items, s)
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, string(b))
+	}
+	return items, nil
+}
+
+// setupParallelRetry links the nodes expanded from parallel steps to
+// their parents. The nodes of a parallel step to be retried are removed
+// so that the step is expanded again.
+func (g *ExecutionGraph) setupParallelRetry() error {
+	nodes := []*Node{}
+	for _, node := range g.nodes {
+		if node.ParallelParent == "" {
+			nodes = append(nodes, node)
+			continue
+		}
+		parent, err := g.findStep(node.ParallelParent)
+		if err != nil {
+			return err
+		}
+		if parent.Status == NodeStatus_None {
+			delete(g.dict, node.id)
+			continue
+		}
+		node.parent = parent
+		parent.children = append(parent.children, node)
+		nodes = append(nodes, node)
+	}
+	g.nodes = nodes
+	return nil
+}
+
 func (g *ExecutionGraph) setupRetry() error {
 	dict := map[int]NodeStatus{}
 	retry := map[int]bool{}
@@ -92,14 +220,15 @@ func (g *ExecutionGraph) setupRetry() error {
 	}
 	frontier := []int{}
 	for _, node := range g.nodes {
-		if len(node.Depends) == 0 {
+		if len(node.Depends) == 0 && node.ParallelParent == "" {
 			frontier = append(frontier, node.id)
 		}
 	}
 	for len(frontier) > 0 {
 		next := []int{}
 		for _, u := range frontier {
-			if retry[u] || dict[u] == NodeStatus_Error || dict[u] == NodeStatus_Cancel {
+			if retry[u] || dict[u] == NodeStatus_Error || dict[u] == NodeStatus_Cancel ||
+				dict[u] == NodeStatus_Timeout {
 				log.Printf("clear node state: %s", g.dict[u].Name)
 				g.dict[u].clearState()
 				retry[u] = true
@@ -116,6 +245,19 @@ func (g *ExecutionGraph) setupRetry() error {
 	return nil
 }
 
+// restoreOutputs loads the output variables of the previous run except
+// for the ones of the steps to be run again.
+func (g *ExecutionGraph) restoreOutputs(outputs map[string]string) {
+	for k, v := range outputs {
+		g.outputVariables.Store(k, fmt.Sprintf("%s=%s", k, v))
+	}
+	for _, node := range g.nodes {
+		if node.Output != "" && node.Status == NodeStatus_None {
+			g.outputVariables.Delete(node.Output)
+		}
+	}
+}
+
 func (g *ExecutionGraph) setup() error {
 	for _, node := range g.nodes {
 		for _, dep := range node.Depends {
@@ -154,7 +296,7 @@ func (g *ExecutionGraph) cycleDfs(t int, visited map[int]bool) error {
 
 func (g *ExecutionGraph) findStep(name string) (*Node, error) {
 	for _, n := range g.dict {
-		if n.Name == name {
+		if n.Name == name && n.ParallelParent == "" {
 			return n, nil
 		}
 	}
@@ -74,7 +74,7 @@ func TestRetryExecution(t *testing.T) {
 			},
 		},
 	}
-	_, err := NewExecutionGraphForRetry(nodes...)
+	_, err := NewExecutionGraphForRetry(nil, nodes...)
 	require.NoError(t, err)
 	require.Equal(t, NodeStatus_Success, nodes[0].Status)
 	require.Equal(t, NodeStatus_None, nodes[1].Status)
@@ -85,3 +85,54 @@ func TestRetryExecution(t *testing.T) {
 	require.Equal(t, NodeStatus_None, nodes[6].Status)
 	require.Equal(t, NodeStatus_Skipped, nodes[7].Status)
 }
+
+func TestRetryParallelExecution(t *testing.T) {
+	parallel := &dag.ParallelConfig{Items: []string{"a", "b"}}
+	nodes := []*Node{
+		{
+			Step:      &dag.Step{Name: "1", Command: "true", Parallel: parallel},
+			NodeState: NodeState{Status: NodeStatus_Success},
+		},
+		{
+			Step:      &dag.Step{Name: "2", Command: "true", Parallel: parallel},
+			NodeState: NodeState{Status: NodeStatus_Error},
+		},
+		{
+			Step:      &dag.Step{Name: "1[a]", Command: "true"},
+			NodeState: NodeState{Status: NodeStatus_Success, ParallelParent: "1"},
+		},
+		{
+			Step:      &dag.Step{Name: "2[a]", Command: "true"},
+			NodeState: NodeState{Status: NodeStatus_Error, ParallelParent: "2"},
+		},
+	}
+	g, err := NewExecutionGraphForRetry(nil, nodes...)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(g.Nodes()))
+	require.Equal(t, NodeStatus_None, nodes[1].Status)
+	require.Equal(t, NodeStatus_Success, nodes[2].Status)
+	require.Equal(t, []*Node{nodes[2]}, nodes[0].children)
+}
+
+func TestRetryOutputs(t *testing.T) {
+	nodes := []*Node{
+		{
+			Step:      &dag.Step{Name: "1", Command: "true", Output: "OUT1"},
+			NodeState: NodeState{Status: NodeStatus_Success},
+		},
+		{
+			Step:      &dag.Step{Name: "2", Command: "true", Output: "OUT2", Depends: []string{"1"}},
+			NodeState: NodeState{Status: NodeStatus_Error},
+		},
+	}
+	g, err := NewExecutionGraphForRetry(map[string]string{
+		"OUT1": `{"id":"x"}`,
+		"OUT2": "stale",
+	}, nodes...)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"OUT1": `{"id":"x"}`}, g.Outputs())
+
+	v, ok := nodes[1].LookupVariable("OUT1.id")
+	require.True(t, ok)
+	require.Equal(t, "x", v)
+}
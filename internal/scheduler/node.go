@@ -11,8 +11,10 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/executor"
 	"github.com/yohamta/dagu/internal/utils"
@@ -28,6 +30,10 @@ const (
 	NodeStatus_Cancel
 	NodeStatus_Success
 	NodeStatus_Skipped
+	NodeStatus_Timeout
+	// NodeStatus_Queued is the status of a node waiting for a slot of
+	// its pool.
+	NodeStatus_Queued
 )
 
 func (s NodeStatus) String() string {
@@ -42,6 +48,10 @@ func (s NodeStatus) String() string {
 		return "finished"
 	case NodeStatus_Skipped:
 		return "skipped"
+	case NodeStatus_Timeout:
+		return "timed out"
+	case NodeStatus_Queued:
+		return "queued"
 	case NodeStatus_None:
 		fallthrough
 	default:
@@ -68,6 +78,16 @@ type Node struct {
 	outputReader *os.File
 	scriptFile   *os.File
 	done         bool
+	requestId    string
+	dagLocation  string
+	parent       *Node
+	children     []*Node
+	timedOut     bool
+	killTimer    *time.Timer
+	gracePeriod  time.Duration
+	// runVariables are the variables of the run given to the step in
+	// addition to its own variables.
+	runVariables []string
 }
 
 // NodeState is the state of a node.
@@ -80,15 +100,23 @@ type NodeState struct {
 	RetriedAt  time.Time
 	DoneCount  int
 	Error      error
+	// ChildRequestId is the request ID of the sub-DAG run started by the node.
+	ChildRequestId string
+	// ParallelParent is the name of the parallel step the node is expanded from.
+	ParallelParent string
+	// SkipReason is the reason the node is skipped as a branch not taken.
+	SkipReason string
 }
 
 // Execute runs the command synchronously and returns error if any.
 func (n *Node) Execute() error {
 	ctx, fn := context.WithCancel(context.Background())
 	n.cancelFunc = fn
+	n.setTimedOut(false)
 
+	var err error
 	if n.CmdWithArgs != "" {
-		n.Command, n.Args = utils.SplitCommand(n.CmdWithArgs, true)
+		n.Command, n.Args = utils.SplitCommandWithMapping(n.CmdWithArgs, n.expandVariable)
 	}
 
 	if n.scriptFile != nil {
@@ -97,7 +125,20 @@ func (n *Node) Execute() error {
 		n.Args = append(args, n.scriptFile.Name())
 	}
 
-	cmd, err := executor.CreateExecutor(ctx, n.Step)
+	if n.Run != "" {
+		if ctx, err = n.setupSubDAG(ctx); err != nil {
+			return err
+		}
+	}
+
+	step := *n.Step
+	step.Variables = []string{}
+	for _, v := range n.Variables {
+		step.Variables = append(step.Variables, utils.ExpandVariablePaths(v, n.expandVariable))
+	}
+	step.Variables = append(step.Variables, n.runVariables...)
+
+	cmd, err := executor.CreateExecutor(ctx, &step)
 	if err != nil {
 		return err
 	}
@@ -129,20 +170,46 @@ func (n *Node) Execute() error {
 		cmd.SetStderr(stdout)
 	}
 
+	if n.Timeout > 0 {
+		timer := time.AfterFunc(n.Timeout, n.timeout)
+		defer timer.Stop()
+	}
+
 	n.Error = cmd.Run()
 
+	if n.stopTimeout() {
+		n.Error = fmt.Errorf("timed out after %s", n.Timeout)
+	}
+
 	if n.outputReader != nil && n.Output != "" {
 		utils.LogErr("close pipe writer", n.outputWriter.Close())
 		var buf bytes.Buffer
 		_, _ = io.Copy(&buf, n.outputReader)
 		ret := strings.TrimSpace(buf.String())
-		os.Setenv(n.Output, ret)
 		n.OutputVariables.Store(n.Output, fmt.Sprintf("%s=%s", n.Output, ret))
 	}
 
 	return n.Error
 }
 
+// expandVariable returns the value of the variable referred to in the
+// command or the conditions of the node. The variables of the run are
+// looked up first as they are not in the environment of the process. The
+// fields of the outputs referred to in the value of an environment
+// variable are expanded as well.
+func (n *Node) expandVariable(key string) string {
+	for _, rv := range n.runVariables {
+		if k, v, _ := strings.Cut(rv, "="); k == key {
+			return v
+		}
+	}
+	v, _ := n.LookupVariable(key)
+	return utils.ExpandVariablePaths(v, func(key string) string {
+		v, _ := n.LookupVariable(key)
+		return v
+	})
+}
+
 // ReadStatus reads the status of a node.
 func (n *Node) ReadStatus() NodeStatus {
 	n.mu.RLock()
@@ -175,8 +242,68 @@ func (n *Node) ReadDoneCount() int {
 	return n.DoneCount
 }
 
+// setupSubDAG assigns a request ID for the sub-DAG run of the node.
+// When the node has run the sub-DAG before, the previous run is retried
+// instead of starting a new one unless the node is a repeating node.
+func (n *Node) setupSubDAG(ctx context.Context) (context.Context, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	run := &executor.SubDAGRun{
+		RequestId:       id.String(),
+		Parent:          n.dagLocation,
+		ParentRequestId: n.requestId,
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.RepeatPolicy.Repeat {
+		run.RetryRequestId = n.ChildRequestId
+	}
+	n.ChildRequestId = run.RequestId
+	return executor.WithSubDAGRun(ctx, run), nil
+}
+
+// skipBranch marks the node skipped as a branch not taken.
+func (n *Node) skipBranch(reason string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Status = NodeStatus_Skipped
+	n.SkipReason = reason
+}
+
+// ReadSkipReason returns the reason the node is skipped as a branch.
+func (n *Node) ReadSkipReason() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.SkipReason
+}
+
+// ReadChildRequestId returns the request ID of the sub-DAG run.
+func (n *Node) ReadChildRequestId() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.ChildRequestId
+}
+
 func (n *Node) clearState() {
-	n.NodeState = NodeState{}
+	// the sub-DAG run is kept to retry it along with the node
+	n.NodeState = NodeState{
+		ChildRequestId: n.ChildRequestId,
+		ParallelParent: n.ParallelParent,
+	}
+	n.children = nil
+}
+
+// runningChildren returns the number of running nodes expanded from
+// the parallel node.
+func (n *Node) runningChildren() (count int) {
+	for _, c := range n.children {
+		if c.ReadStatus() == NodeStatus_Running {
+			count++
+		}
+	}
+	return count
 }
 
 func (n *Node) updateStatus(status NodeStatus) {
@@ -197,16 +324,61 @@ func (n *Node) signal(sig os.Signal, allowOverride bool) {
 		log.Printf("Sending %s signal to %s", sigsig, n.Name)
 		utils.LogErr("sending signal", n.cmd.Kill(sigsig))
 	}
-	if status == NodeStatus_Running {
+	if status == NodeStatus_Running || status == NodeStatus_Queued {
 		n.Status = NodeStatus_Cancel
 	}
 }
 
+// timeout sends the stop signal to the process running over the timeout,
+// and then sends KILL signal if it does not exit within the grace period.
+func (n *Node) timeout() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Status != NodeStatus_Running || n.cmd == nil {
+		return
+	}
+	n.timedOut = true
+	sig := os.Signal(syscall.SIGTERM)
+	if n.SignalOnStop != "" {
+		sig = unix.SignalNum(n.SignalOnStop)
+	}
+	log.Printf("%s timed out after %s, sending %s signal", n.Name, n.Timeout, sig)
+	utils.LogErr("sending signal", n.cmd.Kill(sig))
+	cmd := n.cmd
+	n.killTimer = time.AfterFunc(n.gracePeriod, func() {
+		log.Printf("%s did not exit within %s, sending KILL signal", n.Name, n.gracePeriod)
+		utils.LogErr("sending signal", cmd.Kill(syscall.SIGKILL))
+	})
+}
+
+// stopTimeout stops the pending KILL signal and reports if the node timed out.
+func (n *Node) stopTimeout() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.killTimer != nil {
+		n.killTimer.Stop()
+		n.killTimer = nil
+	}
+	return n.timedOut
+}
+
+func (n *Node) setTimedOut(timedOut bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.timedOut = timedOut
+}
+
+func (n *Node) isTimedOut() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.timedOut
+}
+
 func (n *Node) cancel() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	status := n.Status
-	if status == NodeStatus_Running {
+	if status == NodeStatus_Running || status == NodeStatus_Queued {
 		n.Status = NodeStatus_Cancel
 	}
 	if n.cancelFunc != nil {
@@ -217,6 +389,7 @@ func (n *Node) cancel() {
 
 func (n *Node) setup(logDir string, requestId string) error {
 	n.StartedAt = time.Now()
+	n.requestId = requestId
 	n.Log = filepath.Join(logDir, fmt.Sprintf("%s.%s.%s.log",
 		utils.ValidFilename(n.Name, "_"),
 		n.StartedAt.Format("20060102.15:04:05.000"),
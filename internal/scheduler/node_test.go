@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/executor"
 )
 
 func TestExecute(t *testing.T) {
@@ -35,6 +37,33 @@ func TestError(t *testing.T) {
 	require.Equal(t, n.Error, err)
 }
 
+func TestSetupSubDAG(t *testing.T) {
+	n := &Node{
+		Step: &dag.Step{
+			Run:             "sub",
+			OutputVariables: &sync.Map{},
+		},
+		requestId:   "parent-req",
+		dagLocation: "parent.yaml",
+	}
+
+	ctx, err := n.setupSubDAG(context.Background())
+	require.NoError(t, err)
+	run := executor.SubDAGRunFromContext(ctx)
+	require.Equal(t, "", run.RetryRequestId)
+	require.Equal(t, "parent.yaml", run.Parent)
+	require.Equal(t, "parent-req", run.ParentRequestId)
+	require.Equal(t, run.RequestId, n.ReadChildRequestId())
+
+	// the previous run of the sub-DAG is retried after the state is cleared
+	n.clearState()
+	ctx, err = n.setupSubDAG(context.Background())
+	require.NoError(t, err)
+	retry := executor.SubDAGRunFromContext(ctx)
+	require.Equal(t, run.RequestId, retry.RetryRequestId)
+	require.NotEqual(t, run.RequestId, retry.RequestId)
+}
+
 func TestSignal(t *testing.T) {
 	n := &Node{
 		Step: &dag.Step{
@@ -160,11 +189,12 @@ func TestNode(t *testing.T) {
 }
 
 func TestOutput(t *testing.T) {
+	vars := &sync.Map{}
 	n := &Node{
 		Step: &dag.Step{
 			CmdWithArgs:     "echo hello",
 			Output:          "OUTPUT_TEST",
-			OutputVariables: &sync.Map{},
+			OutputVariables: vars,
 		},
 	}
 	err := n.setup(os.Getenv("HOME"), "test-request-id-output")
@@ -177,19 +207,19 @@ func TestOutput(t *testing.T) {
 
 	dat, _ := os.ReadFile(n.logFile.Name())
 	require.Equal(t, "hello\n", string(dat))
-	require.Equal(t, "hello", os.ExpandEnv("$OUTPUT_TEST"))
+	requireOutput(t, n, "OUTPUT_TEST", "hello")
 
 	// Use the previous output in the subsequent step
 	n2 := &Node{
 		Step: &dag.Step{
 			CmdWithArgs:     "echo $OUTPUT_TEST",
 			Output:          "OUTPUT_TEST2",
-			OutputVariables: &sync.Map{},
+			OutputVariables: vars,
 		},
 	}
 
 	runTestNode(t, n2)
-	require.Equal(t, "hello", os.ExpandEnv("$OUTPUT_TEST2"))
+	requireOutput(t, n2, "OUTPUT_TEST2", "hello")
 
 	// Use the previous output in the subsequent step inside a script
 	n3 := &Node{
@@ -197,12 +227,23 @@ func TestOutput(t *testing.T) {
 			Command:         "sh",
 			Script:          "echo $OUTPUT_TEST2",
 			Output:          "OUTPUT_TEST3",
-			OutputVariables: &sync.Map{},
+			OutputVariables: vars,
 		},
 	}
 
 	runTestNode(t, n3)
-	require.Equal(t, "hello", os.ExpandEnv("$OUTPUT_TEST3"))
+	requireOutput(t, n3, "OUTPUT_TEST3", "hello")
+
+	// The output is not set to the environment of the process
+	_, ok := os.LookupEnv("OUTPUT_TEST")
+	require.False(t, ok)
+}
+
+func requireOutput(t *testing.T, n *Node, key, want string) {
+	t.Helper()
+	v, ok := n.LookupVariable(key)
+	require.True(t, ok)
+	require.Equal(t, want, v)
 }
 
 func TestOutputJson(t *testing.T) {
@@ -242,7 +283,7 @@ func TestOutputJson(t *testing.T) {
 
 			v, _ := n.OutputVariables.Load("OUTPUT_JSON_TEST")
 			require.Equal(t, fmt.Sprintf("OUTPUT_JSON_TEST=%s", test.Want), v)
-			require.Equal(t, test.Want, os.ExpandEnv("$OUTPUT_JSON_TEST"))
+			requireOutput(t, n, "OUTPUT_JSON_TEST", test.Want)
 		})
 	}
 }
@@ -294,7 +335,7 @@ func TestOutputSpecialchar(t *testing.T) {
 
 			v, _ := n.OutputVariables.Load("OUTPUT_SPECIALCHAR_TEST")
 			require.Equal(t, fmt.Sprintf("OUTPUT_SPECIALCHAR_TEST=%s", test.Want), v)
-			require.Equal(t, test.Want, os.ExpandEnv("$OUTPUT_SPECIALCHAR_TEST"))
+			requireOutput(t, n, "OUTPUT_SPECIALCHAR_TEST", test.Want)
 		})
 	}
 }
@@ -326,7 +367,7 @@ func TestRunScript(t *testing.T) {
 	err = n.teardown()
 	require.NoError(t, err)
 
-	require.Equal(t, "hello", os.Getenv("SCRIPT_TEST"))
+	requireOutput(t, n, "SCRIPT_TEST", "hello")
 	require.NoFileExists(t, n.scriptFile.Name())
 }
 
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/yohamta/dagu/internal/constants"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/pool"
 	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/utils"
 )
 
 type SchedulerStatus int
@@ -61,6 +64,17 @@ type Config struct {
 	OnFailure     *dag.Step
 	OnCancel      *dag.Step
 	RequestId     string
+	DAGLocation   string
+	// TimeoutGracePeriod is the time to wait before sending KILL signal
+	// to a step that does not exit after the timeout.
+	TimeoutGracePeriod time.Duration
+	// ArtifactsDir is the directory to store the artifacts of the run.
+	ArtifactsDir string
+	// ScheduledTime is the time the run is scheduled at. It is zero when
+	// the run is not started by the scheduler.
+	ScheduledTime time.Time
+	// Pools are the pools the steps take the slots of by name.
+	Pools map[string]*pool.Pool
 }
 
 // Schedule runs the graph of steps.
@@ -81,7 +95,12 @@ func (sc *Scheduler) Schedule(g *ExecutionGraph, done chan *Node) error {
 			break
 		}
 		for _, node := range g.Nodes() {
-			if node.ReadStatus() != NodeStatus_None {
+			if node.Parallel != nil && node.ReadStatus() == NodeStatus_Running {
+				sc.finishParallel(node)
+				continue
+			}
+			status := node.ReadStatus()
+			if status != NodeStatus_None && status != NodeStatus_Queued {
 				continue
 			}
 			if !isReady(g, node) {
@@ -94,15 +113,46 @@ func (sc *Scheduler) Schedule(g *ExecutionGraph, done chan *Node) error {
 				sc.runningCount(g) >= sc.MaxActiveRuns {
 				continue
 			}
-			if len(node.Preconditions) > 0 {
+			if p := node.parent; p != nil && p.Parallel.MaxConcurrent > 0 &&
+				p.runningChildren() >= p.Parallel.MaxConcurrent {
+				continue
+			}
+			if node.runVariables == nil {
+				node.runVariables = sc.runVariables()
+			}
+			if status == NodeStatus_None && len(node.Preconditions) > 0 {
 				log.Printf("checking pre conditions for \"%s\"", node.Name)
-				if err := dag.EvalConditions(node.Preconditions); err != nil {
+				if err := dag.EvalConditions(dag.ExpandConditions(node.Preconditions, node.expandVariable)); err != nil {
 					log.Printf("%s", err.Error())
 					node.updateStatus(NodeStatus_Skipped)
 					node.Error = err
 					continue
 				}
 			}
+			if status == NodeStatus_None && len(node.When) > 0 {
+				log.Printf("checking branch conditions for \"%s\"", node.Name)
+				err := dag.EvalConditions(dag.ExpandConditions(node.When, node.expandVariable))
+				if errors.Is(err, dag.ErrConditionNotMet) {
+					log.Printf("branch not taken: %s", err.Error())
+					node.skipBranch(fmt.Sprintf("branch not taken: %s", err.Error()))
+					continue
+				}
+				if err != nil {
+					log.Printf("%s", err.Error())
+					node.Error = err
+					sc.lastError = err
+					node.updateStatus(NodeStatus_Error)
+					continue
+				}
+			}
+			if node.Parallel != nil {
+				sc.startParallel(g, node)
+				continue
+			}
+			slot, ok := sc.acquireSlot(node)
+			if !ok {
+				continue
+			}
 			wg.Add(1)
 
 			log.Printf("start running: %s", node.Name)
@@ -110,10 +160,15 @@ func (sc *Scheduler) Schedule(g *ExecutionGraph, done chan *Node) error {
 			go func(node *Node) {
 				defer func() {
 					node.FinishedAt = time.Now()
+					if slot != nil {
+						utils.LogErr("release the slot", slot.Release())
+					}
 					wg.Done()
 				}()
 
 				setup := true
+				node.dagLocation = sc.DAGLocation
+				node.gracePeriod = sc.TimeoutGracePeriod
 				if !sc.Dry {
 					if err := node.setup(sc.LogDir, sc.RequestId); err != nil {
 						setup = false
@@ -140,7 +195,7 @@ func (sc *Scheduler) Schedule(g *ExecutionGraph, done chan *Node) error {
 						switch node.ReadStatus() {
 						case NodeStatus_None:
 							// nothing to do
-						case NodeStatus_Error:
+						case NodeStatus_Error, NodeStatus_Timeout:
 							sc.lastError = err
 						}
 					}
@@ -278,18 +333,24 @@ func (sc *Scheduler) IsCanceled() bool {
 
 func isReady(g *ExecutionGraph, node *Node) (ready bool) {
 	ready = true
+	branchSkipped := 0
 	for _, dep := range g.to[node.id] {
 		n := g.node(dep)
 		switch n.ReadStatus() {
 		case NodeStatus_Success:
 			continue
-		case NodeStatus_Error:
+		case NodeStatus_Error, NodeStatus_Timeout:
 			if !n.ContinueOn.Failure {
 				ready = false
 				node.updateStatus(NodeStatus_Cancel)
 				node.Error = fmt.Errorf("upstream failed")
 			}
 		case NodeStatus_Skipped:
+			if n.ReadSkipReason() != "" {
+				// a node joining branches runs if any of them is taken
+				branchSkipped++
+				continue
+			}
 			if !n.ContinueOn.Skipped {
 				ready = false
 				node.updateStatus(NodeStatus_Skipped)
@@ -302,9 +363,56 @@ func isReady(g *ExecutionGraph, node *Node) (ready bool) {
 			ready = false
 		}
 	}
+	if ready && branchSkipped > 0 && branchSkipped == len(g.to[node.id]) {
+		node.skipBranch("upstream branch not taken")
+		return false
+	}
 	return ready
 }
 
+// acquireSlot takes a slot of the pool of the node. It returns false and
+// queues the node when all of the slots are in use.
+func (sc *Scheduler) acquireSlot(node *Node) (*pool.Slot, bool) {
+	if node.Pool == "" || sc.Dry {
+		return nil, true
+	}
+	p, ok := sc.Pools[node.Pool]
+	if !ok {
+		node.Error = fmt.Errorf("pool %s is not defined", node.Pool)
+		sc.lastError = node.Error
+		node.updateStatus(NodeStatus_Error)
+		return nil, false
+	}
+	slot, err := p.TryAcquire()
+	if err != nil {
+		node.Error = fmt.Errorf("failed to take a slot of pool %s: %w", node.Pool, err)
+		sc.lastError = node.Error
+		node.updateStatus(NodeStatus_Error)
+		return nil, false
+	}
+	if slot == nil {
+		if node.ReadStatus() != NodeStatus_Queued {
+			log.Printf("%s is queued for a slot of pool %s", node.Name, node.Pool)
+			node.updateStatus(NodeStatus_Queued)
+		}
+		return nil, false
+	}
+	return slot, true
+}
+
+// runVariables returns the variables of the run given to the steps.
+func (sc *Scheduler) runVariables() []string {
+	vars := []string{}
+	if sc.ArtifactsDir != "" {
+		vars = append(vars, fmt.Sprintf("%s=%s", constants.ArtifactsDirEnv, sc.ArtifactsDir))
+	}
+	if !sc.ScheduledTime.IsZero() {
+		vars = append(vars, fmt.Sprintf("%s=%s",
+			constants.ScheduledTimeEnv, sc.ScheduledTime.Format(time.RFC3339)))
+	}
+	return vars
+}
+
 func (sc *Scheduler) runHandlerNode(node *Node) error {
 	defer func() {
 		node.FinishedAt = time.Now()
@@ -313,10 +421,14 @@ func (sc *Scheduler) runHandlerNode(node *Node) error {
 	node.updateStatus(NodeStatus_Running)
 
 	if !sc.Dry {
+		node.gracePeriod = sc.TimeoutGracePeriod
+		node.runVariables = sc.runVariables()
 		node.setup(sc.LogDir, sc.RequestId)
 		defer node.teardown()
 		err := node.Execute()
-		if err != nil {
+		if err != nil && node.isTimedOut() {
+			node.updateStatus(NodeStatus_Timeout)
+		} else if err != nil {
 			node.updateStatus(NodeStatus_Error)
 		} else {
 			node.updateStatus(NodeStatus_Success)
@@ -360,19 +472,72 @@ func (sc *Scheduler) setup() (err error) {
 func handleError(node *Node) {
 	status := node.ReadStatus()
 	if status != NodeStatus_Cancel && status != NodeStatus_Success {
-		if node.RetryPolicy != nil && node.RetryPolicy.Limit > node.ReadRetryCount() {
+		if node.RetryPolicy != nil && node.RetryPolicy.Limit > node.ReadRetryCount() &&
+			node.RetryPolicy.RetryOn(exitCode(node.Error)) {
 			log.Printf("%s failed but scheduled for retry", node.Name)
 			node.incRetryCount()
-			log.Printf("sleep %s for retry", node.RetryPolicy.Interval)
-			time.Sleep(node.RetryPolicy.Interval)
+			interval := node.RetryPolicy.IntervalFor(node.ReadRetryCount())
+			log.Printf("sleep %s for retry", interval)
+			time.Sleep(interval)
 			node.SetRetriedAt(time.Now())
 			node.updateStatus(NodeStatus_None)
+		} else if node.isTimedOut() {
+			node.updateStatus(NodeStatus_Timeout)
 		} else {
 			node.updateStatus(NodeStatus_Error)
 		}
 	}
 }
 
+// startParallel expands the parallel node into a node for each item.
+func (sc *Scheduler) startParallel(g *ExecutionGraph, node *Node) {
+	log.Printf("start running: %s", node.Name)
+	node.StartedAt = time.Now()
+	node.updateStatus(NodeStatus_Running)
+	if err := g.expand(node); err != nil {
+		log.Printf("%s failed to expand: %s", node.Name, err)
+		node.Error = err
+		sc.lastError = err
+		node.FinishedAt = time.Now()
+		node.updateStatus(NodeStatus_Error)
+		return
+	}
+	sc.finishParallel(node)
+}
+
+// finishParallel updates the status of the parallel node when all of
+// the expanded nodes have finished.
+func (sc *Scheduler) finishParallel(node *Node) {
+	status, failed := NodeStatus_Success, 0
+	for _, c := range node.children {
+		switch c.ReadStatus() {
+		case NodeStatus_None, NodeStatus_Running, NodeStatus_Queued:
+			return
+		case NodeStatus_Error, NodeStatus_Timeout:
+			failed++
+		case NodeStatus_Cancel:
+			status = NodeStatus_Cancel
+		}
+	}
+	if failed > 0 && status != NodeStatus_Cancel {
+		status = NodeStatus_Error
+		node.Error = fmt.Errorf("%d of %d items failed", failed, len(node.children))
+		sc.lastError = node.Error
+	}
+	node.FinishedAt = time.Now()
+	node.incDoneCount()
+	node.updateStatus(status)
+}
+
+// exitCode returns the exit code of the error, or -1 if it has none.
+func exitCode(err error) int {
+	var e interface{ ExitCode() int }
+	if errors.As(err, &e) {
+		return e.ExitCode()
+	}
+	return -1
+}
+
 func (sc *Scheduler) setCanceled() {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
@@ -382,7 +547,7 @@ func (sc *Scheduler) setCanceled() {
 func (sc *Scheduler) isRunning(g *ExecutionGraph) bool {
 	for _, node := range g.Nodes() {
 		switch node.ReadStatus() {
-		case NodeStatus_Running:
+		case NodeStatus_Running, NodeStatus_Queued:
 			return true
 		}
 	}
@@ -392,6 +557,10 @@ func (sc *Scheduler) isRunning(g *ExecutionGraph) bool {
 func (sc *Scheduler) runningCount(g *ExecutionGraph) (count int) {
 	count = 0
 	for _, node := range g.Nodes() {
+		if node.Parallel != nil {
+			// the expanded nodes are counted instead
+			continue
+		}
 		switch node.ReadStatus() {
 		case NodeStatus_Running:
 			count++
@@ -403,7 +572,7 @@ func (sc *Scheduler) runningCount(g *ExecutionGraph) (count int) {
 func (sc *Scheduler) isFinished(g *ExecutionGraph) bool {
 	for _, node := range g.Nodes() {
 		switch node.ReadStatus() {
-		case NodeStatus_Running, NodeStatus_None:
+		case NodeStatus_Running, NodeStatus_None, NodeStatus_Queued:
 			return false
 		}
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/yohamta/dagu/internal/constants"
 	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/pool"
 	"github.com/yohamta/dagu/internal/settings"
 	"github.com/yohamta/dagu/internal/utils"
 )
@@ -607,7 +608,48 @@ func TestTakeOutputFromPrevStep(t *testing.T) {
 	require.Equal(t, NodeStatus_Success, nodes[0].ReadStatus())
 	require.Equal(t, NodeStatus_Success, nodes[1].ReadStatus())
 
-	require.Equal(t, "take-output", os.ExpandEnv("$TOOK_PREV_OUT"))
+	require.Equal(t, map[string]string{
+		"PREV_OUT":      "take-output",
+		"TOOK_PREV_OUT": "take-output",
+	}, g.Outputs())
+
+	// outputs are not leaked into the environment of the process
+	_, ok := os.LookupEnv("TOOK_PREV_OUT")
+	require.False(t, ok)
+}
+
+func TestTakeJSONOutputFromPrevStep(t *testing.T) {
+	s1 := step("1", `echo '{"items":[{"id":"a1"},{"id":"a2"}],"count":2}'`)
+	s1.Output = "RESULT"
+
+	s2 := step("2", "", "1")
+	s2.CmdWithArgs = "echo ${RESULT.items[1].id}"
+	s2.Output = "ID"
+
+	s3 := step("3", "", "1")
+	s3.CmdWithArgs = "echo ${RESULT.count}"
+	s3.Output = "COUNT"
+	s3.Preconditions = []*dag.Condition{
+		{Condition: "${RESULT.items[0].id}", Expected: "a1"},
+	}
+
+	s4 := step("4", "echo skipped", "1")
+	s4.Preconditions = []*dag.Condition{
+		{Condition: "${RESULT.count}", Expected: "> 2"},
+	}
+
+	g, sc := newTestSchedule(t, &Config{}, s1, s2, s3, s4)
+	err := sc.Schedule(g, nil)
+	require.NoError(t, err)
+
+	nodes := g.Nodes()
+	require.Equal(t, NodeStatus_Success, nodes[1].ReadStatus())
+	require.Equal(t, NodeStatus_Success, nodes[2].ReadStatus())
+	require.Equal(t, NodeStatus_Skipped, nodes[3].ReadStatus())
+
+	outputs := g.Outputs()
+	require.Equal(t, "a2", outputs["ID"])
+	require.Equal(t, "2", outputs["COUNT"])
 }
 
 func step(name, command string, depends ...string) *dag.Step {
@@ -637,3 +679,181 @@ func newTestSchedule(t *testing.T, cfg *Config, steps ...*dag.Step) (
 	require.NoError(t, err)
 	return g, &Scheduler{Config: cfg}
 }
+
+func TestSchedulerParallelItems(t *testing.T) {
+	s1 := step("1", "sh")
+	s1.Script = `test "$ITEM" = a -o "$ITEM" = b -o "$ITEM" = c`
+	s1.Parallel = &dag.ParallelConfig{
+		Items:         []string{"a", "b", "c"},
+		MaxConcurrent: 1,
+	}
+	s2 := step("2", testCommand, "1")
+
+	g, sc, err := testSchedule(t, s1, s2)
+	require.NoError(t, err)
+	require.Equal(t, SchedulerStatus_Success, sc.Status(g))
+
+	nodes := g.Nodes()
+	require.Equal(t, 5, len(nodes))
+	for _, n := range nodes {
+		require.Equal(t, NodeStatus_Success, n.ReadStatus(), n.Name)
+	}
+	require.Equal(t, "1[a]", nodes[2].Name)
+	require.Equal(t, "1", nodes[2].ParallelParent)
+	require.True(t, nodes[1].StartedAt.After(nodes[4].FinishedAt))
+}
+
+func TestSchedulerParallelFromOutput(t *testing.T) {
+	s1 := step("1", `echo '["x", "y"]'`)
+	s1.Output = "PARALLEL_ITEMS"
+	s2 := step("2", "sh", "1")
+	s2.Script = `test "$ITEM" = x`
+	s2.Parallel = &dag.ParallelConfig{Variable: "PARALLEL_ITEMS"}
+	s3 := step("3", testCommand, "2")
+
+	g, sc, err := testSchedule(t, s1, s2, s3)
+	require.Error(t, err)
+	require.Equal(t, SchedulerStatus_Error, sc.Status(g))
+
+	nodes := g.Nodes()
+	require.Equal(t, 5, len(nodes))
+	require.Equal(t, NodeStatus_Error, nodes[1].ReadStatus())
+	require.Equal(t, "1 of 2 items failed", nodes[1].Error.Error())
+	require.Equal(t, NodeStatus_Cancel, nodes[2].ReadStatus())
+	require.Equal(t, NodeStatus_Success, nodes[3].ReadStatus())
+	require.Equal(t, NodeStatus_Error, nodes[4].ReadStatus())
+}
+
+func TestSchedulerParallelDuplicateItems(t *testing.T) {
+	s1 := step("1", `echo '["x", "x"]'`)
+	s1.Output = "PARALLEL_ITEMS"
+	s2 := step("2", testCommand, "1")
+	s2.Parallel = &dag.ParallelConfig{Variable: "PARALLEL_ITEMS"}
+
+	g, sc, err := testSchedule(t, s1, s2)
+	require.Error(t, err)
+	require.Equal(t, SchedulerStatus_Error, sc.Status(g))
+
+	nodes := g.Nodes()
+	require.Equal(t, 2, len(nodes))
+	require.Equal(t, NodeStatus_Error, nodes[1].ReadStatus())
+	require.Equal(t, "duplicate item for parallel: x", nodes[1].Error.Error())
+}
+
+func TestSchedulerTimeout(t *testing.T) {
+	s1 := step("1", "sleep 10")
+	s1.Timeout = time.Millisecond * 100
+	s2 := step("2", testCommand, "1")
+
+	g, sc := newTestSchedule(t, &Config{}, s1, s2)
+	err := sc.Schedule(g, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out")
+	require.Equal(t, SchedulerStatus_Error, sc.Status(g))
+
+	nodes := g.Nodes()
+	require.Equal(t, NodeStatus_Timeout, nodes[0].ReadStatus())
+	require.Equal(t, "timed out", nodes[0].ReadStatus().String())
+	require.Equal(t, NodeStatus_Cancel, nodes[1].ReadStatus())
+}
+
+func TestSchedulerTimeoutKill(t *testing.T) {
+	s1 := step("1", "sh")
+	s1.Script = "trap '' TERM; sleep 10"
+	s1.Timeout = time.Millisecond * 100
+
+	g, sc := newTestSchedule(t,
+		&Config{TimeoutGracePeriod: time.Millisecond * 100}, s1)
+
+	start := time.Now()
+	require.Error(t, sc.Schedule(g, nil))
+	require.Less(t, time.Since(start), time.Second*5)
+	require.Equal(t, NodeStatus_Timeout, g.Nodes()[0].ReadStatus())
+}
+
+func TestSchedulerRetryOnExitCode(t *testing.T) {
+	s1 := step("1", `sh -c "exit 1"`)
+	s1.RetryPolicy = &dag.RetryPolicy{Limit: 2, ExitCodes: []int{75}}
+	s2 := step("2", `sh -c "exit 75"`)
+	s2.RetryPolicy = &dag.RetryPolicy{Limit: 2, ExitCodes: []int{75}}
+
+	g, _, err := testSchedule(t, s1, s2)
+	require.Error(t, err)
+
+	nodes := g.Nodes()
+	require.Equal(t, NodeStatus_Error, nodes[0].ReadStatus())
+	require.Equal(t, 0, nodes[0].ReadRetryCount())
+	require.Equal(t, NodeStatus_Error, nodes[1].ReadStatus())
+	require.Equal(t, 2, nodes[1].ReadRetryCount())
+}
+
+func TestSchedulerBranch(t *testing.T) {
+	s1 := step("1", "echo full")
+	s1.Output = "BRANCH_MODE"
+	full := step("full", testCommand, "1")
+	full.When = []*dag.Condition{{Condition: "$BRANCH_MODE", Expected: "full"}}
+	incr := step("incremental", testCommand, "1")
+	incr.When = []*dag.Condition{{Condition: "$BRANCH_MODE", Expected: "full", Not: true}}
+	afterIncr := step("after incremental", testCommand, "incremental")
+	join := step("join", testCommand, "full", "after incremental")
+
+	g, sc, err := testSchedule(t, s1, full, incr, afterIncr, join)
+	require.NoError(t, err)
+	require.Equal(t, SchedulerStatus_Success, sc.Status(g))
+
+	nodes := g.Nodes()
+	require.Equal(t, NodeStatus_Success, nodes[1].ReadStatus())
+	require.Equal(t, NodeStatus_Skipped, nodes[2].ReadStatus())
+	require.Contains(t, nodes[2].ReadSkipReason(), "branch not taken")
+	require.Nil(t, nodes[2].Error)
+	require.Equal(t, NodeStatus_Skipped, nodes[3].ReadStatus())
+	require.Equal(t, "upstream branch not taken", nodes[3].ReadSkipReason())
+	require.Equal(t, NodeStatus_Success, nodes[4].ReadStatus())
+}
+
+func TestSchedulerPool(t *testing.T) {
+	s1 := step("1", "sleep 1")
+	s1.Pool = "test"
+	s2 := step("2", "sleep 1")
+	s2.Pool = "test"
+	s3 := step("3", testCommand)
+	s3.Pool = "undefined"
+
+	p := &pool.Pool{Name: "test", Slots: 1, Dir: t.TempDir()}
+	g, sc := newTestSchedule(t,
+		&Config{
+			MaxActiveRuns: 1000,
+			Pools:         map[string]*pool.Pool{"test": p},
+		},
+		s1, s2, s3,
+	)
+
+	queued := make(chan bool)
+	go func() {
+		defer close(queued)
+		for i := 0; i < 20; i++ {
+			for _, n := range g.Nodes() {
+				if n.ReadStatus() == NodeStatus_Queued {
+					queued <- true
+					return
+				}
+			}
+			time.Sleep(time.Millisecond * 50)
+		}
+	}()
+
+	require.Error(t, sc.Schedule(g, nil))
+	require.True(t, <-queued)
+
+	nodes := g.Nodes()
+	require.Equal(t, NodeStatus_Success, nodes[0].ReadStatus())
+	require.Equal(t, NodeStatus_Success, nodes[1].ReadStatus())
+	require.Equal(t, NodeStatus_Error, nodes[2].ReadStatus())
+
+	// the steps in the pool run one at a time
+	first, second := nodes[0], nodes[1]
+	if second.StartedAt.Before(first.StartedAt) {
+		first, second = second, first
+	}
+	require.False(t, second.StartedAt.Before(first.FinishedAt))
+}
@@ -0,0 +1,152 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yohamta/dagu/internal/constants"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/database"
+	"github.com/yohamta/dagu/internal/executor"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+// histSize is the number of the recent runs searched for a run of a date.
+var histSize = 100
+
+// Matches returns whether the run has the status.
+func Matches(s *models.Status, status dag.RunStatus) bool {
+	switch status {
+	case dag.RunStatusSuccess:
+		return s.Status == scheduler.SchedulerStatus_Success
+	case dag.RunStatusFailed:
+		return s.Status == scheduler.SchedulerStatus_Error
+	case dag.RunStatusCanceled:
+		return s.Status == scheduler.SchedulerStatus_Cancel
+	}
+	return false
+}
+
+// LatestRun returns the status of the latest run of the DAG at the location,
+// or the latest one of the logical date if date is not empty. It returns
+// nil if there is no such run.
+func LatestRun(location, date string) *models.Status {
+	n := 1
+	if date != "" {
+		n = histSize
+	}
+	for _, f := range database.New().ReadStatusHist(location, n) {
+		if date == "" || LogicalDate(f.Status) == date {
+			return f.Status
+		}
+	}
+	return nil
+}
+
+// LogicalDate returns the date the run is scheduled at, or the date it
+// started at if it is not started by the scheduler.
+func LogicalDate(s *models.Status) string {
+	t := s.ScheduledTime
+	if t == "" {
+		t = s.StartedAt
+	}
+	if len(t) < len("2006-01-02") {
+		return ""
+	}
+	return t[:len("2006-01-02")]
+}
+
+// WaitForExecutor waits for a run of another DAG to have the status.
+type WaitForExecutor struct {
+	stdout   io.Writer
+	ctx      context.Context
+	cancel   context.CancelFunc
+	waitFor  *dag.WaitFor
+	location string
+	date     string
+}
+
+func (e *WaitForExecutor) SetStdout(out io.Writer) {
+	e.stdout = out
+}
+
+func (e *WaitForExecutor) SetStderr(out io.Writer) {
+}
+
+func (e *WaitForExecutor) Kill(sig os.Signal) error {
+	e.cancel()
+	return nil
+}
+
+func (e *WaitForExecutor) Run() error {
+	var timeout <-chan time.Time
+	if e.waitFor.Timeout > 0 {
+		timeout = time.After(e.waitFor.Timeout)
+	}
+	for {
+		s := LatestRun(e.location, e.date)
+		if s == nil {
+			fmt.Fprintf(e.stdout, "%s has no run\n", e.waitFor.DAG)
+		} else {
+			fmt.Fprintf(e.stdout, "%s %s: %s\n", e.waitFor.DAG, s.RequestId, s.StatusText)
+			if Matches(s, e.waitFor.Status) {
+				return nil
+			}
+		}
+		select {
+		case <-e.ctx.Done():
+			return fmt.Errorf("waiting for %s is canceled", e.waitFor.DAG)
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for %s to be %s", e.waitFor.DAG, e.waitFor.Status)
+		case <-time.After(e.waitFor.PokeInterval):
+		}
+	}
+}
+
+func CreateWaitForExecutor(ctx context.Context, step *dag.Step) (executor.Executor, error) {
+	if step.WaitFor == nil {
+		return nil, fmt.Errorf("DAG to wait for must be specified")
+	}
+	location, err := filepath.Abs(dag.ResolveFile(step.Dir, step.WaitFor.DAG))
+	if err != nil {
+		return nil, err
+	}
+	date := ""
+	if step.WaitFor.SameDate {
+		date, err = runDate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &WaitForExecutor{
+		stdout:   os.Stdout,
+		ctx:      ctx,
+		cancel:   cancel,
+		waitFor:  step.WaitFor,
+		location: location,
+		date:     date,
+	}, nil
+}
+
+// runDate returns the logical date of the run of the step.
+func runDate() (string, error) {
+	t := utils.Now()
+	if v := os.Getenv(constants.ScheduledTimeEnv); v != "" {
+		var err error
+		if t, err = time.Parse(time.RFC3339, v); err != nil {
+			return "", err
+		}
+	}
+	return t.In(time.Local).Format("2006-01-02"), nil
+}
+
+func init() {
+	executor.Register(dag.ExecutorTypeWaitFor, CreateWaitForExecutor)
+}
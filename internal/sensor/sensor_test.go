@@ -0,0 +1,100 @@
+package sensor
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yohamta/dagu/internal/dag"
+	"github.com/yohamta/dagu/internal/database"
+	"github.com/yohamta/dagu/internal/models"
+	"github.com/yohamta/dagu/internal/scheduler"
+	"github.com/yohamta/dagu/internal/settings"
+	"github.com/yohamta/dagu/internal/utils"
+)
+
+func TestMain(m *testing.M) {
+	tempDir := utils.MustTempDir("sensor_test")
+	settings.ChangeHomeDir(tempDir)
+	code := m.Run()
+	os.RemoveAll(tempDir)
+	os.Exit(code)
+}
+
+func writeRun(t *testing.T, d *dag.DAG, requestId string, status scheduler.SchedulerStatus, startedAt time.Time, scheduledTime string) {
+	t.Helper()
+	w, _, err := database.New().NewWriter(d.Location, startedAt, requestId)
+	require.NoError(t, err)
+	require.NoError(t, w.Open())
+	defer w.Close()
+	s := models.NewStatus(d, nil, status, 0, &startedAt, &startedAt)
+	s.RequestId = requestId
+	s.ScheduledTime = scheduledTime
+	require.NoError(t, w.Write(s))
+}
+
+func TestLatestRun(t *testing.T) {
+	d := &dag.DAG{Name: "upstream", Location: path.Join(t.TempDir(), "upstream.yaml")}
+	require.Nil(t, LatestRun(d.Location, ""))
+
+	writeRun(t, d, "run-1", scheduler.SchedulerStatus_Success,
+		time.Date(2022, 1, 1, 1, 0, 0, 0, time.Local), "2022-01-01 00:00:00")
+	writeRun(t, d, "run-2", scheduler.SchedulerStatus_Error,
+		time.Date(2022, 1, 2, 1, 0, 0, 0, time.Local), "")
+
+	s := LatestRun(d.Location, "")
+	require.Equal(t, "run-2", s.RequestId)
+	require.True(t, Matches(s, dag.RunStatusFailed))
+	require.False(t, Matches(s, dag.RunStatusSuccess))
+
+	s = LatestRun(d.Location, "2022-01-01")
+	require.Equal(t, "run-1", s.RequestId)
+	require.True(t, Matches(s, dag.RunStatusSuccess))
+
+	require.Equal(t, "run-2", LatestRun(d.Location, "2022-01-02").RequestId)
+	require.Nil(t, LatestRun(d.Location, "2022-01-03"))
+}
+
+func TestWaitForExecutor(t *testing.T) {
+	dir := t.TempDir()
+	d := &dag.DAG{Name: "upstream", Location: path.Join(dir, "upstream.yaml")}
+	step := &dag.Step{
+		Dir: dir,
+		WaitFor: &dag.WaitFor{
+			DAG:          "upstream",
+			Status:       dag.RunStatusSuccess,
+			PokeInterval: time.Millisecond * 10,
+			Timeout:      time.Millisecond * 100,
+		},
+	}
+
+	e, err := CreateWaitForExecutor(context.Background(), step)
+	require.NoError(t, err)
+	e.SetStdout(io.Discard)
+	require.ErrorContains(t, e.Run(), "timed out")
+
+	writeRun(t, d, "run-1", scheduler.SchedulerStatus_Success, time.Now(), "")
+	e, err = CreateWaitForExecutor(context.Background(), step)
+	require.NoError(t, err)
+	e.SetStdout(io.Discard)
+	require.NoError(t, e.Run())
+
+	// wait for the run of the same date as the scheduled time
+	step.WaitFor.SameDate = true
+	step.WaitFor.Timeout = 0
+	t.Setenv("DAGU_SCHEDULED_TIME", "2022-01-01T00:00:00Z")
+	e, err = CreateWaitForExecutor(context.Background(), step)
+	require.NoError(t, err)
+	e.SetStdout(io.Discard)
+	done := make(chan error)
+	go func() {
+		done <- e.Run()
+	}()
+	time.Sleep(time.Millisecond * 50)
+	require.NoError(t, e.Kill(os.Interrupt))
+	require.ErrorContains(t, <-done, "canceled")
+}
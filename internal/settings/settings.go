@@ -25,6 +25,11 @@ const (
 	SETTING__ADMIN_CONFIG      = "DAGU__ADMIN_CONFIG"
 	SETTING__ADMIN_LOGS_DIR    = "DAGU__ADMIN_LOGS_DIR"
 	SETTING__ADMIN_DAGS_DIR    = "DAGU__ADMIN_DAGS_DIR"
+	SETTING__AUDIT_LOG         = "DAGU__AUDIT_LOG"
+	SETTING__REVISIONS_DIR     = "DAGU__REVISIONS_DIR"
+	SETTING__POOLS_DIR         = "DAGU__POOLS_DIR"
+	SETTING__QUEUE_DIR         = "DAGU__QUEUE_DIR"
+	SETTING__TRIGGERS_DIR      = "DAGU__TRIGGERS_DIR"
 )
 
 // MustGet returns the value of the setting or
@@ -76,6 +81,11 @@ func load() {
 	cache[SETTING__SUSPEND_FLAGS_DIR] = path.Join(dh, "/suspend")
 	cache[SETTING__ADMIN_LOGS_DIR] = path.Join(dh, "/logs/admin")
 	cache[SETTING__ADMIN_DAGS_DIR] = path.Join(dh, "/dags")
+	cache[SETTING__AUDIT_LOG] = path.Join(dh, "/audit/audit.log")
+	cacheEnv(SETTING__REVISIONS_DIR, path.Join(dh, "/revisions"))
+	cacheEnv(SETTING__POOLS_DIR, path.Join(dh, "/pools"))
+	cacheEnv(SETTING__QUEUE_DIR, path.Join(dh, "/queue"))
+	cacheEnv(SETTING__TRIGGERS_DIR, path.Join(dh, "/triggers"))
 	cache[SETTING__ADMIN_PORT] = "8080"
 	cache[SETTING__ADMIN_NAVBAR_COLOR] = ""
 	cache[SETTING__ADMIN_NAVBAR_TITLE] = "Dagu"
@@ -55,6 +55,18 @@ func TestReadSetting(t *testing.T) {
 			Name: SETTING__LOGS_DIR,
 			Want: "/tmp/dagu/logs",
 		},
+		{
+			Name: SETTING__REVISIONS_DIR,
+			Want: "/tmp/revisions",
+		},
+		{
+			Name: SETTING__POOLS_DIR,
+			Want: "/tmp/pools",
+		},
+		{
+			Name: SETTING__QUEUE_DIR,
+			Want: "/tmp/queue",
+		},
 	} {
 		_ = os.Setenv(test.Name, test.Want)
 		load()
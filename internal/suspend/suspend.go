@@ -2,6 +2,8 @@ package suspend
 
 import (
 	"fmt"
+	"net/url"
+	"path"
 
 	"github.com/yohamta/dagu/internal/dag"
 	"github.com/yohamta/dagu/internal/storage"
@@ -32,5 +34,9 @@ func (s *SuspendChecker) IsSuspended(d *dag.DAG) bool {
 }
 
 func fileName(d *dag.DAG) string {
+	if d.Subdir != "" {
+		// the DAGs in subdirectories may have the same name
+		return fmt.Sprintf("%s.suspend", url.PathEscape(path.Join(d.Subdir, d.Name)))
+	}
 	return fmt.Sprintf("%s.suspend", utils.ValidFilename(d.Name, "-"))
 }
@@ -36,4 +36,11 @@ func TestSuspendChecker(t *testing.T) {
 
 	suspend = sc.IsSuspended(d)
 	require.False(t, suspend)
+
+	// the DAGs with the same name in different subdirectories
+	nested := &dag.DAG{Name: "test", Subdir: "team-a"}
+	require.NoError(t, sc.ToggleSuspend(nested, true))
+	require.True(t, sc.IsSuspended(nested))
+	require.False(t, sc.IsSuspended(d))
+	require.False(t, sc.IsSuspended(&dag.DAG{Name: "test", Subdir: "team-b"}))
 }
@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -63,10 +67,19 @@ func FormatDuration(t time.Duration, defaultVal string) string {
 
 // SplitCommand splits command string to program and arguments.
 func SplitCommand(cmd string, parse bool) (program string, args []string) {
-	s := cmd
 	if parse {
-		s = os.ExpandEnv(cmd)
+		return SplitCommandWithMapping(cmd, os.Getenv)
 	}
+	return splitCommand(cmd, false)
+}
+
+// SplitCommandWithMapping splits command string to program and arguments
+// after replacing the variables in it by the mapping.
+func SplitCommandWithMapping(cmd string, mapping func(string) string) (program string, args []string) {
+	return splitCommand(os.Expand(cmd, mapping), true)
+}
+
+func splitCommand(s string, parse bool) (program string, args []string) {
 	vals := strings.SplitN(s, " ", 2)
 	if len(vals) > 1 {
 		program = vals[0]
@@ -146,6 +159,32 @@ var (
 	filenameReservedWindowsNamesRegex = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])$`)
 )
 
+// CopyDir copies the files in the src directory to the dst directory.
+func CopyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, b, info.Mode())
+	})
+}
+
 // ValidFilename returns true if filename is valid.
 func ValidFilename(str, replacement string) string {
 	s := filenameReservedRegex.ReplaceAllString(str, replacement)
@@ -185,6 +224,98 @@ func ParseCommand(value string) (string, error) {
 	return ret, nil
 }
 
+// ParseEnvVariable parses the value of an environment variable like
+// ParseVariable, but leaves the references to a field of a variable such
+// as ${RESULT.items[0].id} to be expanded by ExpandVariablePaths when the
+// step runs, as the variable may be the output of a previous step.
+func ParseEnvVariable(value string) (string, error) {
+	return ParseCommand(os.Expand(value, func(key string) string {
+		if _, path := SplitVariablePath(key); path != "" {
+			return "${" + key + "}"
+		}
+		return os.Getenv(key)
+	}))
+}
+
+var variablePathRegex = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*[.\[][^}]*\}`)
+
+// ExpandVariablePaths replaces the references to a field of a variable such
+// as ${RESULT.items[0].id} in the string with the values returned by mapping.
+// Other references are left as they are.
+func ExpandVariablePaths(str string, mapping func(string) string) string {
+	return variablePathRegex.ReplaceAllStringFunc(str, func(m string) string {
+		return mapping(m[2 : len(m)-1])
+	})
+}
+
+// SplitVariablePath splits a variable reference such as "RESULT.items[0].id"
+// into the name of the variable and the path to the field in it.
+func SplitVariablePath(key string) (name, path string) {
+	i := strings.IndexAny(key, ".[")
+	if i < 0 {
+		return key, ""
+	}
+	return key[:i], key[i:]
+}
+
+// ExtractJSONPath returns the value at the path such as ".items[0].id" in
+// the JSON data. A string value is returned as is and others as JSON.
+func ExtractJSONPath(data, path string) (string, error) {
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return "", err
+	}
+	for p := path; p != ""; {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			i := strings.IndexAny(p, ".[")
+			if i < 0 {
+				i = len(p)
+			}
+			key := p[:i]
+			p = p[i:]
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("value is not an object: %s", key)
+			}
+			if v, ok = m[key]; !ok {
+				return "", fmt.Errorf("field not found: %s", key)
+			}
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return "", fmt.Errorf("invalid path: %s", path)
+			}
+			idx, err := strconv.Atoi(p[1:end])
+			if err != nil {
+				return "", fmt.Errorf("invalid index: %s", p[1:end])
+			}
+			p = p[end+1:]
+			list, ok := v.([]interface{})
+			if !ok {
+				return "", fmt.Errorf("value is not an array: [%d]", idx)
+			}
+			if idx < 0 || idx >= len(list) {
+				return "", fmt.Errorf("index out of range: [%d]", idx)
+			}
+			v = list[idx]
+		default:
+			return "", fmt.Errorf("invalid path: %s", path)
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 // MustTempDir returns temporary directory.
 func MustTempDir(pattern string) string {
 	t, err := os.MkdirTemp("", pattern)
@@ -62,6 +62,59 @@ func TestSplitCommand(t *testing.T) {
 	require.Equal(t, "test/", args[1])
 }
 
+func TestSplitCommandWithMapping(t *testing.T) {
+	vars := map[string]string{"RESULT.items[0].id": "a b"}
+	program, args := utils.SplitCommandWithMapping(
+		"echo ${RESULT.items[0].id} $UNKNOWN `echo x`",
+		func(k string) string { return vars[k] })
+	require.Equal(t, "echo", program)
+	require.Equal(t, []string{"a", "b", "x"}, args)
+}
+
+func TestExtractJSONPath(t *testing.T) {
+	data := `{"items":[{"id":1,"name":"a"},{"id":12345678901234567890}],"msg":"ok"}`
+	for path, want := range map[string]string{
+		"":               data,
+		".msg":           "ok",
+		".items[0].id":   "1",
+		".items[0]":      `{"id":1,"name":"a"}`,
+		".items[1].id":   "12345678901234567890",
+		".items[0].name": "a",
+	} {
+		ret, err := utils.ExtractJSONPath(data, path)
+		require.NoError(t, err)
+		if path == "" {
+			require.JSONEq(t, want, ret)
+			continue
+		}
+		require.Equal(t, want, ret, path)
+	}
+	for _, path := range []string{".none", ".items[2]", ".msg[0]", ".items[x]", "items"} {
+		_, err := utils.ExtractJSONPath(data, path)
+		require.Error(t, err, path)
+	}
+	_, err := utils.ExtractJSONPath("not json", ".a")
+	require.Error(t, err)
+
+	name, path := utils.SplitVariablePath("RESULT.items[0].id")
+	require.Equal(t, "RESULT", name)
+	require.Equal(t, ".items[0].id", path)
+}
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	dst := path.Join(t.TempDir(), "dst")
+	require.NoError(t, os.MkdirAll(path.Join(src, "sub"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(src, "sub", "a.txt"), []byte("a"), 0600))
+
+	require.NoError(t, utils.CopyDir(src, dst))
+	b, err := os.ReadFile(path.Join(dst, "sub", "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "a", string(b))
+
+	require.Error(t, utils.CopyDir(path.Join(src, "none"), dst))
+}
+
 func TestFileExits(t *testing.T) {
 	require.True(t, utils.FileExists("/"))
 }
@@ -128,6 +181,19 @@ func TestParseVariable(t *testing.T) {
 	require.Equal(t, r, "test")
 }
 
+func TestParseEnvVariable(t *testing.T) {
+	os.Setenv("TEST_VAR", "test")
+	r, err := utils.ParseEnvVariable("${TEST_VAR} ${RESULT.items[0].id}")
+	require.NoError(t, err)
+	require.Equal(t, "test ${RESULT.items[0].id}", r)
+
+	r = utils.ExpandVariablePaths(r+" $TEST_VAR", func(key string) string {
+		require.Equal(t, "RESULT.items[0].id", key)
+		return "a1"
+	})
+	require.Equal(t, "test a1 $TEST_VAR", r)
+}
+
 func TestMustTempDir(t *testing.T) {
 	dir := utils.MustTempDir("tempdir")
 	defer os.RemoveAll(dir)